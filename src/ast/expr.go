@@ -23,6 +23,23 @@ func NewLogical(left Expr, op lexer.Token, right Expr) *Logical {
 
 func (t *Logical) marker() {}
 
+// --- Conditional expression: cond ? a : b
+type Conditional struct {
+	Condition Expr
+	Then      Expr
+	Else      Expr
+}
+
+func NewConditional(condition Expr, then Expr, otherwise Expr) *Conditional {
+	return &Conditional{
+		Condition: condition,
+		Then:      then,
+		Else:      otherwise,
+	}
+}
+
+func (t *Conditional) marker() {}
+
 // --- Binary expression: 1 + 2
 type Binary struct {
 	Left     Expr
@@ -71,18 +88,59 @@ type Call struct {
 	Callee Expr
 	Paren  lexer.Token // --- used to report runtime errors
 	Args   []Expr
+	// --- arguments passed by name, which always follow the positional ones
+	Named []NamedArg
 }
 
-func NewCall(callee Expr, paren lexer.Token, args []Expr) *Call {
+// argument of a call passed by name: f(name: value)
+type NamedArg struct {
+	Name  lexer.Token
+	Value Expr
+}
+
+func NewCall(callee Expr, paren lexer.Token, args []Expr, named []NamedArg) *Call {
 	return &Call{
 		Callee: callee,
 		Paren:  paren,
 		Args:   args,
+		Named:  named,
 	}
 }
 
 func (t *Call) marker() {}
 
+// --- subscript: list[i]
+type Index struct {
+	Object  Expr
+	Bracket lexer.Token // --- used to report runtime errors
+	Index   Expr
+}
+
+func NewIndex(object Expr, bracket lexer.Token, index Expr) *Index {
+	return &Index{
+		Object:  object,
+		Bracket: bracket,
+		Index:   index,
+	}
+}
+
+func (t *Index) marker() {}
+
+// --- property access: error.message
+type Get struct {
+	Object Expr
+	Name   lexer.Token
+}
+
+func NewGet(object Expr, name lexer.Token) *Get {
+	return &Get{
+		Object: object,
+		Name:   name,
+	}
+}
+
+func (t *Get) marker() {}
+
 // --- Unary expression: !true | -1337
 type Unary struct {
 	Operator   lexer.Token
@@ -125,3 +183,44 @@ func NewAssignment(name lexer.Token, val Expr) *Assignment {
 }
 
 func (t *Assignment) marker() {}
+
+// --- Update expression: ++x, x--. The new value is computed by Assignment, whose value is
+// Variable plus or minus one; Prefix decides whether the expression evaluates to the new or the old value
+type Update struct {
+	Operator   lexer.Token
+	Prefix     bool
+	Variable   *Variable
+	Assignment *Assignment
+}
+
+func NewUpdate(op lexer.Token, prefix bool, variable *Variable) *Update {
+	binaryOp := lexer.PLUS
+	if op.TokenType() == lexer.MINUS_MINUS {
+		binaryOp = lexer.MINUS
+	}
+
+	value := NewBinary(NewVariable(variable.Name), op.WithType(binaryOp), NewLiteral(int64(1)))
+	return &Update{
+		Operator:   op,
+		Prefix:     prefix,
+		Variable:   variable,
+		Assignment: NewAssignment(variable.Name, value),
+	}
+}
+
+func (t *Update) marker() {}
+
+// --- Interpolation expression: "a ${b} c"
+type Interpolation struct {
+	Start lexer.Token // --- used to report runtime errors
+	Parts []Expr      // --- string literals for the text segments, any expression for the embedded ones
+}
+
+func NewInterpolation(start lexer.Token, parts []Expr) *Interpolation {
+	return &Interpolation{
+		Start: start,
+		Parts: parts,
+	}
+}
+
+func (t *Interpolation) marker() {}
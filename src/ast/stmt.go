@@ -10,28 +10,112 @@ type Stmt interface {
 
 // return
 type ReturnStatement struct {
+	Keyword lexer.Token // --- used to report static errors
+	// nil if no value is returned
 	Expression Expr
 }
 
-func NewReturnStatement(expr Expr) *ReturnStatement {
+func NewReturnStatement(keyword lexer.Token, expr Expr) *ReturnStatement {
 	return &ReturnStatement{
+		Keyword:    keyword,
 		Expression: expr,
 	}
 }
 
 func (t *ReturnStatement) stmtMarker() {}
 
+// throw
+type ThrowStatement struct {
+	Keyword    lexer.Token // --- used to report runtime errors
+	Expression Expr
+}
+
+func NewThrowStatement(keyword lexer.Token, expr Expr) *ThrowStatement {
+	return &ThrowStatement{
+		Keyword:    keyword,
+		Expression: expr,
+	}
+}
+
+func (t *ThrowStatement) stmtMarker() {}
+
+// catch clause of a try statement
+type CatchClause struct {
+	// binds the caught value in Body
+	Name lexer.Token
+	Body []Stmt
+}
+
+// try statement - at least one of Catch and Finally is set
+type TryStatement struct {
+	Keyword lexer.Token // --- used to report runtime errors
+	Body    []Stmt
+	// nil if there is no catch clause
+	Catch *CatchClause
+	// nil if there is no finally clause
+	Finally []Stmt
+}
+
+func NewTryStatement(keyword lexer.Token, body []Stmt, catch *CatchClause, finally []Stmt) *TryStatement {
+	return &TryStatement{
+		Keyword: keyword,
+		Body:    body,
+		Catch:   catch,
+		Finally: finally,
+	}
+}
+
+func (t *TryStatement) stmtMarker() {}
+
+// case of a match statement
+type MatchCase struct {
+	Keyword lexer.Token // --- 'case' or 'default', used to report errors
+	// the case matches if any pattern equals the matched value, nil for the default case
+	Patterns []Expr
+	Body     []Stmt
+}
+
+// match statement - runs the body of the first case matching the value, or the default one. There is no fall-through
+type MatchStatement struct {
+	Keyword lexer.Token // --- used to report runtime errors
+	Value   Expr
+	Cases   []*MatchCase
+	// nil if there is no default case
+	Default *MatchCase
+}
+
+func NewMatchStatement(keyword lexer.Token, value Expr, cases []*MatchCase, def *MatchCase) *MatchStatement {
+	return &MatchStatement{
+		Keyword: keyword,
+		Value:   value,
+		Cases:   cases,
+		Default: def,
+	}
+}
+
+func (t *MatchStatement) stmtMarker() {}
+
+// parameter of a function declaration
+type Parameter struct {
+	Name lexer.Token
+	// evaluated at call time when no argument is given, nil for required parameters
+	Default Expr
+}
+
 // funcDecl - function declarations
 type FunctionStatement struct {
 	Name       lexer.Token
-	Parameters []lexer.Token
-	Body       []Stmt
+	Parameters []Parameter
+	// collects the arguments past the last parameter into a list, nil if the function is not variadic
+	Rest *lexer.Token
+	Body []Stmt
 }
 
-func NewFunctionStatement(name lexer.Token, parms []lexer.Token, body []Stmt) *FunctionStatement {
+func NewFunctionStatement(name lexer.Token, parms []Parameter, rest *lexer.Token, body []Stmt) *FunctionStatement {
 	return &FunctionStatement{
 		Name:       name,
 		Parameters: parms,
+		Rest:       rest,
 		Body:       body,
 	}
 }
@@ -40,6 +124,7 @@ func (t *FunctionStatement) stmtMarker() {}
 
 // for statements
 type ForStatement struct {
+	Keyword lexer.Token // --- used to report runtime errors
 	// runs once before execution, can be a statement or a variable declaration for convenience - optional
 	Initializer Stmt
 	// condition that gets checked in the begining of each iteration - optional
@@ -49,8 +134,9 @@ type ForStatement struct {
 	Body      Stmt
 }
 
-func NewForStatement(init Stmt, cond Expr, incr Expr, body Stmt) *ForStatement {
+func NewForStatement(keyword lexer.Token, init Stmt, cond Expr, incr Expr, body Stmt) *ForStatement {
 	return &ForStatement{
+		Keyword:     keyword,
 		Initializer: init,
 		Condition:   cond,
 		Increment:   incr,
@@ -123,6 +209,8 @@ type VariableStatement struct {
 	Name lexer.Token
 	// nil if no initializer exists
 	Initializer *Expr
+	// declared with 'const': always initialized, and can not be assigned to
+	Constant bool
 }
 
 func NewVariableStatement(name lexer.Token, init *Expr) *VariableStatement {
@@ -136,11 +224,13 @@ func (t *VariableStatement) stmtMarker() {}
 
 // block - group of statements
 type BlockStatement struct {
+	Brace      lexer.Token // --- opening '{', used to report runtime errors
 	Statements []Stmt
 }
 
-func NewBlockStatement(statements []Stmt) *BlockStatement {
+func NewBlockStatement(brace lexer.Token, statements []Stmt) *BlockStatement {
 	return &BlockStatement{
+		Brace:      brace,
 		Statements: statements,
 	}
 }
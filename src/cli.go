@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"golox/src/executor"
+	"io"
+	"os"
+)
+
+const version = "0.1.0"
+
+const usageHeader = `Usage:
+  golox [flags]                              start an interactive session
+  golox [flags] <file.lox | -> [args...]     run a script ('-' reads it from stdin)
+  golox [flags] run <file.lox | -> [args...] run a script
+  golox [flags] check <file.lox | ->...      lex, parse and resolve scripts without running them
+  golox [flags] repl                         start an interactive session
+  golox [flags] -e <code> [args...]          run code passed on the command line
+
+Flags:
+`
+
+type options struct {
+	eval        string
+	showVersion bool
+	maxDepth    int
+	maxSteps    int
+	sandbox     string
+	maxMemory   int64
+}
+
+// assumes the options have already been validated by runCLI
+func (opts options) executorOptions(args []string) executor.Options {
+	sandbox, _ := executor.SandboxByName(opts.sandbox)
+
+	return executor.Options{
+		Args:        args,
+		MaxDepth:    opts.maxDepth,
+		MaxSteps:    opts.maxSteps,
+		Sandbox:     &sandbox,
+		MemoryLimit: opts.maxMemory,
+	}
+}
+
+func newFlagSet(opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("golox", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	// --- usage is printed by runCLI, to stdout or stderr depending on how it was requested
+	fs.Usage = func() {}
+
+	fs.StringVar(&opts.eval, "e", "", "run `code` instead of reading a script")
+	fs.BoolVar(&opts.showVersion, "version", false, "print the version and exit")
+	fs.IntVar(&opts.maxDepth, "max-depth", executor.DefaultMaxDepth, "maximum `depth` of nested function calls")
+	fs.StringVar(&opts.sandbox, "sandbox", executor.SandboxTrusted.Name, "natives available to scripts, one of: "+executor.SandboxNames())
+	fs.Int64Var(&opts.maxMemory, "max-memory", 0, "fail scripts holding more than `bytes` at once (0 for no limit)")
+	fs.IntVar(&opts.maxSteps, "max-steps", 0, "interrupt scripts after evaluating `n` statements and expressions (0 for no limit)")
+
+	return fs
+}
+
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprint(w, usageHeader)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fs.SetOutput(os.Stderr)
+}
+
+// parses args and dispatches to the requested subcommand, returning the process exit code
+func runCLI(args []string) int {
+	opts := options{}
+	fs := newFlagSet(&opts)
+
+	// --- reports a usage error and returns the matching exit code
+	usageError := func(msg string) int {
+		if msg != "" {
+			fmt.Fprintf(os.Stderr, "[ERROR]: %s\n", msg)
+		}
+		fmt.Fprintln(os.Stderr, "run 'golox --help' for usage")
+		return exitUsage
+	}
+
+	// --- flags are accepted both before and after the subcommand
+	parse := func(args []string) (bool, int) {
+		err := fs.Parse(args)
+		if errors.Is(err, flag.ErrHelp) {
+			printUsage(os.Stdout, fs)
+			return false, exitOK
+		} else if err != nil {
+			// --- the flag package has already reported the error
+			return false, usageError("")
+		}
+		return true, exitOK
+	}
+
+	if ok, code := parse(args); !ok {
+		return code
+	}
+
+	command := ""
+	rest := fs.Args()
+	if len(rest) > 0 {
+		switch rest[0] {
+		case "run", "check", "repl", "help":
+			command = rest[0]
+			if ok, code := parse(rest[1:]); !ok {
+				return code
+			}
+			rest = fs.Args()
+		}
+	}
+
+	if opts.maxDepth <= 0 {
+		return usageError("--max-depth must be positive")
+	}
+	if opts.maxDepth > executor.MaxDepthLimit {
+		return usageError(fmt.Sprintf("--max-depth can not exceed %d", executor.MaxDepthLimit))
+	}
+	if opts.maxSteps < 0 {
+		return usageError("--max-steps can not be negative")
+	}
+	if opts.maxMemory < 0 {
+		return usageError("--max-memory can not be negative")
+	}
+	if _, ok := executor.SandboxByName(opts.sandbox); !ok {
+		return usageError(fmt.Sprintf("unknown sandbox '%s', expected one of: %s", opts.sandbox, executor.SandboxNames()))
+	}
+
+	if opts.showVersion {
+		fmt.Printf("golox %s\n", version)
+		return exitOK
+	}
+
+	// --- code given on the command line, everything else is passed on to the script
+	isEval := false
+	fs.Visit(func(f *flag.Flag) { isEval = isEval || f.Name == "e" })
+	if isEval {
+		if command != "" && command != "run" {
+			return usageError(fmt.Sprintf("-e can not be used with '%s'", command))
+		}
+		return HandleSourceInput(opts.eval, opts.executorOptions(rest))
+	}
+
+	switch command {
+	case "help":
+		printUsage(os.Stdout, fs)
+		return exitOK
+	case "repl":
+		if len(rest) > 0 {
+			return usageError("'repl' does not take any arguments")
+		}
+		return HandleReplInput(opts.executorOptions(nil))
+	case "check":
+		if len(rest) == 0 {
+			return usageError("'check' expects at least one file")
+		}
+		return HandleCheckInput(rest, opts.executorOptions(nil))
+	case "run":
+		if len(rest) == 0 {
+			return usageError("'run' expects a file")
+		}
+		return HandleFileInput(rest[0], opts.executorOptions(rest[1:]))
+	}
+
+	// --- no subcommand: run the given file, or start the REPL
+	if len(rest) == 0 {
+		return HandleReplInput(opts.executorOptions(nil))
+	}
+	return HandleFileInput(rest[0], opts.executorOptions(rest[1:]))
+}
@@ -1,7 +1,10 @@
 package executor
 
 import (
+	"fmt"
 	"golox/src/ast"
+	"golox/src/lexer"
+	"golox/src/suggest"
 )
 
 func assert(condition bool, msg string) {
@@ -13,8 +16,67 @@ func assert(condition bool, msg string) {
 }
 
 type Callable interface {
-	call(executor *Executor, args []any) (any, error)
-	arity() int
+	// --- paren is the call site, used to report runtime errors
+	call(executor *Executor, paren lexer.Token, args []any) (any, error)
+	// --- minimum and maximum number of arguments, the maximum is -1 for variadic functions
+	arity() (int, int)
+}
+
+// --- placeholder for a parameter left out of a call, which takes its default value
+type missingArgument struct{}
+
+// argument passed by name, already evaluated
+type namedArgument struct {
+	name  lexer.Token
+	value any
+}
+
+// checks the arguments of a call against the callable and returns them in parameter order. Arguments
+// passed by name are moved to the position of their parameter, and parameters left out are filled
+// with a missingArgument
+func bindArguments(callable Callable, paren lexer.Token, args []any, named []namedArgument) ([]any, error) {
+	minArgs, maxArgs := callable.arity()
+	got := len(args) + len(named)
+	if got < minArgs || (maxArgs >= 0 && len(args) > maxArgs) {
+		return nil, NewArityError(paren, minArgs, maxArgs, got)
+	}
+
+	fun, ok := callable.(*GoloxFunction)
+	if !ok {
+		if len(named) > 0 {
+			return nil, NewTypeError(named[0].name, fmt.Sprintf("%s does not accept named arguments", callable), "function", callable)
+		}
+		return args, nil
+	}
+
+	params := fun.decl.Parameters
+	bound := make([]any, max(len(params), len(args)))
+	copy(bound, args)
+	for i := len(args); i < len(params); i++ {
+		bound[i] = missingArgument{}
+	}
+
+	for _, arg := range named {
+		i := fun.paramIndex(arg.name.Literal())
+		if i < 0 {
+			return nil, fun.unknownParameterError(arg.name)
+		}
+		if _, missing := bound[i].(missingArgument); !missing {
+			return nil, NewNameError(arg.name, fmt.Sprintf("argument for parameter '%s' of %s is given more than once", arg.name.Literal(), fun))
+		}
+		bound[i] = arg.value
+	}
+
+	for i, param := range params {
+		if _, missing := bound[i].(missingArgument); missing && param.Default == nil {
+			// --- enough arguments were given, but named ones took the place of a required parameter
+			err := NewArityError(paren, minArgs, maxArgs, got)
+			err.Msg = fmt.Sprintf("missing argument for parameter '%s' of %s", param.Name.Literal(), fun)
+			return nil, err
+		}
+	}
+
+	return bound, nil
 }
 
 type GoloxFunction struct {
@@ -30,7 +92,13 @@ func NewGoloxFunction(decl ast.FunctionStatement, env *Environment) *GoloxFuncti
 }
 
 // --- assumes all arity checks have already been done, but maybe worth moving this here
-func (fun *GoloxFunction) call(executor *Executor, args []any) (r any, e error) {
+func (fun *GoloxFunction) call(executor *Executor, paren lexer.Token, args []any) (r any, e error) {
+	err := executor.pushFrame(fun.decl.Name.Literal(), paren)
+	if err != nil {
+		return nil, err
+	}
+	defer executor.popFrame()
+
 	defer func() {
 		if raw := recover(); raw != nil {
 			// --- if the panic holds a ReturnValue, handle it, otherwise re-panic
@@ -43,22 +111,90 @@ func (fun *GoloxFunction) call(executor *Executor, args []any) (r any, e error)
 		}
 	}()
 
-	env := NewEnvironment(fun.closure)
+	env, err := executor.newEnvironment(paren, fun.closure)
+	if err != nil {
+		return nil, executor.withTrace(err)
+	}
+	defer executor.release(env)
 
 	// --- bind the args with the respective params
-	assert(len(fun.decl.Parameters) == len(args), "incorrect number of arguments for function call")
-	for i, arg := range args {
-		env.Set(fun.decl.Parameters[i].Literal(), arg)
+	assert(len(args) >= len(fun.decl.Parameters), "incorrect number of arguments for function call")
+	for i, param := range fun.decl.Parameters {
+		arg := args[i]
+		if _, missing := arg.(missingArgument); missing {
+			arg, err = executor.evalDefault(param.Default, env)
+			if err != nil {
+				return nil, executor.withTrace(err)
+			}
+		}
+		if err := executor.bind(param.Name, env, param.Name.Literal(), arg); err != nil {
+			return nil, executor.withTrace(err)
+		}
+		env.Set(param.Name.Literal(), arg)
 	}
 
-	_, err := executor.execBlock(fun.decl.Body, env)
+	// --- extra arguments are collected in a new list
+	if fun.decl.Rest != nil {
+		rest := make([]any, len(args)-len(fun.decl.Parameters))
+		copy(rest, args[len(fun.decl.Parameters):])
+		list := NewList(rest)
+		if err := executor.bind(*fun.decl.Rest, env, fun.decl.Rest.Literal(), list); err != nil {
+			return nil, executor.withTrace(err)
+		}
+		env.Set(fun.decl.Rest.Literal(), list)
+	}
+
+	_, err = executor.execBlock(fun.decl.Body, env)
 	if err != nil {
-		return nil, err
+		return nil, executor.withTrace(err)
 	}
 
 	return nil, nil
 }
 
-func (fun *GoloxFunction) arity() int {
-	return len(fun.decl.Parameters)
+func (fun *GoloxFunction) arity() (int, int) {
+	min := 0
+	for _, param := range fun.decl.Parameters {
+		if param.Default == nil {
+			min++
+		}
+	}
+
+	if fun.decl.Rest != nil {
+		return min, -1
+	}
+	return min, len(fun.decl.Parameters)
+}
+
+// returns the position of the parameter called name, or -1 if there is none
+func (fun *GoloxFunction) paramIndex(name string) int {
+	for i, param := range fun.decl.Parameters {
+		if param.Name.Literal() == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (fun *GoloxFunction) unknownParameterError(name lexer.Token) error {
+	if fun.decl.Rest != nil && fun.decl.Rest.Literal() == name.Literal() {
+		return NewTypeError(name, fmt.Sprintf("rest parameter '%s' of %s can not be passed by name", name.Literal(), fun), "function", fun)
+	}
+
+	names := make([]string, 0, len(fun.decl.Parameters))
+	for _, param := range fun.decl.Parameters {
+		names = append(names, param.Name.Literal())
+	}
+
+	err := NewNameError(name, fmt.Sprintf("%s has no parameter named '%s'", fun, name.Literal()))
+	err.Suggestions = suggest.Closest(name.Literal(), names)
+	if hint := suggest.Hint(err.Suggestions); hint != "" {
+		err.Msg = fmt.Sprintf("%s; %s", err.Msg, hint)
+	}
+	return err
+}
+
+func (fun *GoloxFunction) String() string {
+	return fmt.Sprintf("<fn %s>", fun.decl.Name.Literal())
 }
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"golox/src/lexer"
+)
+
+// --- an active function call
+type frame struct {
+	name string
+	// token of the call expression that created the frame
+	callSite lexer.Token
+}
+
+// --- a line of a stack trace, innermost first
+type TraceEntry struct {
+	Function string
+	Line     int
+}
+
+// pushes a new frame, raising a stack overflow instead of letting the Go runtime abort the process
+func (exec *Executor) pushFrame(name string, callSite lexer.Token) error {
+	if len(exec.frames) >= exec.maxDepth {
+		msg := fmt.Sprintf("stack overflow: maximum call depth of %d exceeded", exec.maxDepth)
+		return exec.withTrace(NewLimitError(callSite, msg, "call depth", int64(exec.maxDepth)))
+	}
+
+	exec.frames = append(exec.frames, frame{name: name, callSite: callSite})
+	return nil
+}
+
+func (exec *Executor) popFrame() {
+	exec.frames = exec.frames[:len(exec.frames)-1]
+}
+
+// builds the stack trace for an error raised at line. Each frame is reported at the line
+// it was executing: the innermost one at line, every other at the call site of the frame above it
+func (exec *Executor) stackTrace(line int) []TraceEntry {
+	trace := make([]TraceEntry, 0, len(exec.frames)+1)
+	for i := len(exec.frames) - 1; i >= 0; i-- {
+		trace = append(trace, TraceEntry{Function: exec.frames[i].name, Line: line})
+		line = exec.frames[i].callSite.Line()
+	}
+
+	return append(trace, TraceEntry{Function: "<script>", Line: line})
+}
+
+// attaches the current stack trace to err if it is a RuntimeError without one. Must be called
+// before any frame is popped, so that the trace reflects where the error was raised
+func (exec *Executor) withTrace(err error) error {
+	var runtimeErr *RuntimeError
+	if errors.As(err, &runtimeErr) && runtimeErr.Trace == nil {
+		runtimeErr.Trace = exec.stackTrace(runtimeErr.Token.Line())
+	}
+
+	return err
+}
@@ -7,13 +7,21 @@ import (
 
 type Environment struct {
 	store map[string]any
+	// --- declarations of the names in store that are constants
+	constants map[string]lexer.Token
 	// --- reference to enclosing environment
 	enclosing *Environment
+	// --- bytes accounted to this scope, see Executor.allocate
+	size int64
+	// --- whether a closure refers to this scope, keeping it alive after it is left
+	captured bool
+	left     bool
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
 	return &Environment{
 		store:     make(map[string]any),
+		constants: make(map[string]lexer.Token),
 		enclosing: enclosing,
 	}
 }
@@ -22,6 +30,19 @@ func (env *Environment) Set(key string, value any) {
 	env.store[key] = value
 }
 
+// --- declares name in env, failing if it already holds a constant. Other names can be redeclared
+func (env *Environment) Define(name lexer.Token, value any, constant bool) error {
+	if decl, ok := env.constants[name.Literal()]; ok {
+		return NewConstError(name, "can not redeclare constant", decl)
+	}
+
+	env.Set(name.Literal(), value)
+	if constant {
+		env.constants[name.Literal()] = name
+	}
+	return nil
+}
+
 // --- for better error logging, do so in the caller to get
 func (env *Environment) Get(key lexer.Token) (any, error) {
 	val, ok := env.store[key.Literal()]
@@ -34,13 +55,39 @@ func (env *Environment) Get(key lexer.Token) (any, error) {
 		return env.enclosing.Get(key)
 	}
 
-	return nil, NewRuntimeError(key, fmt.Sprintf("undefined variable name '%s'", key.Literal()))
+	return nil, NewNameError(key, fmt.Sprintf("undefined variable name '%s'", key.Literal()))
+}
+
+// --- returns the names visible from env, innermost scope first
+func (env *Environment) Names() []string {
+	names := make([]string, 0)
+	for cur := env; cur != nil; cur = cur.enclosing {
+		for name := range cur.store {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// --- returns the environment defining key, searching the enclosing ones, or nil if there is none
+func (env *Environment) holder(key string) *Environment {
+	for cur := env; cur != nil; cur = cur.enclosing {
+		if _, ok := cur.store[key]; ok {
+			return cur
+		}
+	}
+
+	return nil
 }
 
 // --- tries to reasign key to value, returning value if successfull and an error if failed
 func (env *Environment) Assign(key lexer.Token, value any) (any, error) {
 	_, ok := env.store[key.Literal()]
 	if ok {
+		if decl, constant := env.constants[key.Literal()]; constant {
+			return nil, NewConstError(key, "can not assign to constant", decl)
+		}
 		env.Set(key.Literal(), value)
 		return value, nil
 	}
@@ -50,5 +97,5 @@ func (env *Environment) Assign(key lexer.Token, value any) (any, error) {
 		return env.enclosing.Assign(key, value)
 	}
 
-	return nil, NewRuntimeError(key, fmt.Sprintf("invalid assignment: variable '%s' does not exist", key.Literal()))
+	return nil, NewNameError(key, fmt.Sprintf("invalid assignment: variable '%s' does not exist", key.Literal()))
 }
@@ -1,24 +1,256 @@
 package executor
 
 import (
+	"errors"
 	"fmt"
 	"golox/src/lexer"
+	"strings"
 )
 
+// --- base of every error a script can catch. Raised errors are usually one of the kinds below,
+// which wrap a RuntimeError and can be matched with errors.As or errors.Is
 type RuntimeError struct {
 	Token lexer.Token
 	Msg   string
+	// --- name of the kind, empty for errors raised by throw statements and errors without a more specific kind
+	kind string
+	// call stack at the point the error was raised, innermost first
+	Trace []TraceEntry
+	// --- value bound by catch clauses: set by throw statements, or on the first catch otherwise. See caughtValue
+	thrown bool
+	value  any
 }
 
-func NewRuntimeError(token lexer.Token, msg string) RuntimeError {
-	return RuntimeError{
+func NewRuntimeError(token lexer.Token, msg string) *RuntimeError {
+	return &RuntimeError{
 		Token: token,
 		Msg:   msg,
 	}
 }
 
-func (err RuntimeError) Error() string {
-	return fmt.Sprintf("[ERROR]: runtime error at line %d: %s", err.Token.Line(), err.Msg)
+func newKindError(token lexer.Token, kind string, msg string) *RuntimeError {
+	err := NewRuntimeError(token, msg)
+	err.kind = kind
+	return err
+}
+
+// returns the name of the error kind, e.g. "TypeError", or "RuntimeError" for errors without a kind, like those raised by throw statements
+func (err *RuntimeError) Kind() string {
+	if err.kind == "" {
+		return "RuntimeError"
+	}
+
+	return err.kind
+}
+
+func (err *RuntimeError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[ERROR]: %s at line %d: %s", err.Kind(), err.Token.Line(), err.Msg)
+	for i := 0; i < len(err.Trace); {
+		entry := err.Trace[i]
+		run := 1
+		for i+run < len(err.Trace) && err.Trace[i+run] == entry {
+			run++
+		}
+
+		// --- collapse long runs of identical entries, as deep recursion would otherwise flood the output
+		if run > 3 {
+			fmt.Fprintf(&sb, "\n    at %s (line %d)", entry.Function, entry.Line)
+			fmt.Fprintf(&sb, "\n    ... repeated %d more times", run-1)
+		} else {
+			for j := 0; j < run; j++ {
+				fmt.Fprintf(&sb, "\n    at %s (line %d)", entry.Function, entry.Line)
+			}
+		}
+		i += run
+	}
+
+	return sb.String()
+}
+
+// --- sentinels matching each error kind with errors.Is
+var (
+	ErrType         = errors.New("type error")
+	ErrName         = errors.New("name error")
+	ErrArity        = errors.New("arity error")
+	ErrZeroDivision = errors.New("zero division error")
+	ErrNotCallable  = errors.New("not callable error")
+	ErrLimit        = errors.New("limit error")
+	ErrOverflow     = errors.New("overflow error")
+	ErrConst        = errors.New("const error")
+)
+
+// --- operand or argument of the wrong type
+type TypeError struct {
+	*RuntimeError
+	// description of the accepted types, e.g. "number or string"
+	Expected string
+	// type of the offending value, as returned by TypeName
+	Actual string
+}
+
+func NewTypeError(token lexer.Token, msg string, expected string, actual any) *TypeError {
+	return &TypeError{
+		RuntimeError: newKindError(token, "TypeError", msg),
+		Expected:     expected,
+		Actual:       TypeName(actual),
+	}
+}
+
+func (err *TypeError) Unwrap() error        { return err.RuntimeError }
+func (err *TypeError) Is(target error) bool { return target == ErrType }
+
+// --- reference to a name that is not defined
+type NameError struct {
+	*RuntimeError
+	Name string
+	// visible names close to Name, best first
+	Suggestions []string
+}
+
+func NewNameError(token lexer.Token, msg string) *NameError {
+	return &NameError{
+		RuntimeError: newKindError(token, "NameError", msg),
+		Name:         token.Literal(),
+	}
+}
+
+func (err *NameError) Unwrap() error        { return err.RuntimeError }
+func (err *NameError) Is(target error) bool { return target == ErrName }
+
+// --- call with the wrong number of arguments
+type ArityError struct {
+	*RuntimeError
+	// accepted number of arguments, Max is -1 for variadic functions
+	Min int
+	Max int
+	Got int
+}
+
+func NewArityError(token lexer.Token, min int, max int, got int) *ArityError {
+	var expected string
+	switch {
+	case min == max:
+		expected = fmt.Sprintf("%d", min)
+	case max < 0:
+		expected = fmt.Sprintf("at least %d", min)
+	default:
+		expected = fmt.Sprintf("between %d and %d", min, max)
+	}
+
+	return &ArityError{
+		RuntimeError: newKindError(token, "ArityError", fmt.Sprintf("invalid number of arguments: expected %s but got %d", expected, got)),
+		Min:          min,
+		Max:          max,
+		Got:          got,
+	}
+}
+
+func (err *ArityError) Unwrap() error        { return err.RuntimeError }
+func (err *ArityError) Is(target error) bool { return target == ErrArity }
+
+// --- division by zero
+type ZeroDivisionError struct {
+	*RuntimeError
+}
+
+func NewZeroDivisionError(token lexer.Token) *ZeroDivisionError {
+	return &ZeroDivisionError{
+		RuntimeError: newKindError(token, "ZeroDivisionError", "right side of division can not be zero"),
+	}
+}
+
+func (err *ZeroDivisionError) Unwrap() error        { return err.RuntimeError }
+func (err *ZeroDivisionError) Is(target error) bool { return target == ErrZeroDivision }
+
+// --- call of a value that is not a function
+type NotCallableError struct {
+	*RuntimeError
+	// type of the callee, as returned by TypeName
+	Type string
+}
+
+func NewNotCallableError(token lexer.Token, callee any) *NotCallableError {
+	return &NotCallableError{
+		RuntimeError: newKindError(token, "NotCallableError", fmt.Sprintf("expression of type %s is not callable", TypeName(callee))),
+		Type:         TypeName(callee),
+	}
+}
+
+func (err *NotCallableError) Unwrap() error        { return err.RuntimeError }
+func (err *NotCallableError) Is(target error) bool { return target == ErrNotCallable }
+
+// --- integer arithmetic or conversion whose result does not fit in 64 bits
+type OverflowError struct {
+	*RuntimeError
+}
+
+func NewOverflowError(token lexer.Token, msg string) *OverflowError {
+	return &OverflowError{
+		RuntimeError: newKindError(token, "OverflowError", msg),
+	}
+}
+
+func (err *OverflowError) Unwrap() error        { return err.RuntimeError }
+func (err *OverflowError) Is(target error) bool { return target == ErrOverflow }
+
+// --- assignment to, or redeclaration of, a constant
+type ConstError struct {
+	*RuntimeError
+	Name string
+	// line of the constant's declaration
+	DeclLine int
+}
+
+func NewConstError(token lexer.Token, msg string, decl lexer.Token) *ConstError {
+	return &ConstError{
+		RuntimeError: newKindError(token, "ConstError", fmt.Sprintf("%s '%s' declared at line %d", msg, token.Literal(), decl.Line())),
+		Name:         token.Literal(),
+		DeclLine:     decl.Line(),
+	}
+}
+
+func (err *ConstError) Unwrap() error        { return err.RuntimeError }
+func (err *ConstError) Is(target error) bool { return target == ErrConst }
+
+// --- resource limit exceeded by the script. Unlike an InterruptError, scripts can catch it
+type LimitError struct {
+	*RuntimeError
+	// the exceeded limit, e.g. "call depth" or "memory"
+	Limit string
+	Max   int64
+}
+
+func NewLimitError(token lexer.Token, msg string, limit string, max int64) *LimitError {
+	return &LimitError{
+		RuntimeError: newKindError(token, "LimitError", msg),
+		Limit:        limit,
+		Max:          max,
+	}
+}
+
+func (err *LimitError) Unwrap() error        { return err.RuntimeError }
+func (err *LimitError) Is(target error) bool { return target == ErrLimit }
+
+// --- cause of an InterruptError when Options.MaxSteps is exceeded
+var ErrStepBudgetExceeded = errors.New("step budget exceeded")
+
+// --- returned when execution is stopped from the outside, either because the context passed to ExecuteContext
+// is done or because the step budget ran out. Match the cause with errors.Is
+type InterruptError struct {
+	Cause error
+}
+
+func NewInterruptError(cause error) *InterruptError {
+	return &InterruptError{Cause: cause}
+}
+
+func (err *InterruptError) Error() string {
+	return fmt.Sprintf("[ERROR]: execution interrupted: %s", err.Cause)
+}
+
+func (err *InterruptError) Unwrap() error {
+	return err.Cause
 }
 
 type ReturnValue struct {
@@ -32,3 +264,16 @@ func NewReturnValue(val any) ReturnValue {
 func (err ReturnValue) Error() string {
 	return fmt.Sprintf("%s", err.val)
 }
+
+// --- returned when a script calls exit(): not a failure, but it stops execution all the way up to the host
+type ExitError struct {
+	Code int
+}
+
+func NewExitError(code int) ExitError {
+	return ExitError{Code: code}
+}
+
+func (err ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", err.Code)
+}
@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"fmt"
+	"golox/src/lexer"
+)
+
+// --- values exposing properties through '.' expressions
+type propertyHolder interface {
+	get(name lexer.Token) (any, error)
+}
+
+// --- runtime error raised by the executor and caught by a script
+type ErrorValue struct {
+	Kind    string
+	Message string
+	Line    int
+	// --- the error that was caught, raised again as is when the value is rethrown
+	err error
+}
+
+// wraps the runtime error err, raised as original, e.g. a *TypeError embedding err
+func NewErrorValue(err *RuntimeError, original error) *ErrorValue {
+	return &ErrorValue{
+		Kind:    err.Kind(),
+		Message: err.Msg,
+		Line:    err.Token.Line(),
+		err:     original,
+	}
+}
+
+func (e *ErrorValue) get(name lexer.Token) (any, error) {
+	switch name.Literal() {
+	case "message":
+		return e.Message, nil
+	case "line":
+		return int64(e.Line), nil
+	case "kind":
+		return e.Kind, nil
+	}
+
+	return nil, NewNameError(name, fmt.Sprintf("undefined property '%s' on error", name.Literal()))
+}
+
+func (e *ErrorValue) String() string {
+	return e.Message
+}
+
+// builds the error raised by a throw statement
+func NewThrownError(keyword lexer.Token, value any) error {
+	// --- rethrowing a caught error raises the original one, so it keeps its type, message and trace
+	if errValue, ok := value.(*ErrorValue); ok && errValue.err != nil {
+		return errValue.err
+	}
+
+	err := NewRuntimeError(keyword, fmt.Sprintf("uncaught exception: %s", Stringify(value)))
+	err.thrown = true
+	err.value = value
+	return err
+}
+
+// returns the value a catch clause binds for err, where original is the error as raised
+func (err *RuntimeError) caughtValue(original error) any {
+	if !err.thrown && err.value == nil {
+		err.value = NewErrorValue(err, original)
+	}
+
+	return err.value
+}
@@ -1,29 +1,92 @@
 package executor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"golox/src/ast"
 	"golox/src/lexer"
 	"strconv"
 )
 
+// maximum call depth used when Options.MaxDepth is not set
+const DefaultMaxDepth = 4096
+
+// largest supported call depth. Every call nests several Go frames, so deeper recursion could exhaust
+// the Go stack and crash the process before the stack overflow is raised
+const MaxDepthLimit = 50000
+
+// configures an Executor. The zero value is valid
+type Options struct {
+	// arguments passed on to the script, exposed through the args() and argc() natives
+	Args []string
+	// maximum number of nested function calls before a stack overflow is raised. Defaults to DefaultMaxDepth
+	// and is capped at MaxDepthLimit
+	MaxDepth int
+	// maximum number of statements and expressions evaluated before execution is interrupted. Zero means no limit
+	MaxSteps int
+	// natives available to the script. Defaults to SandboxTrusted
+	Sandbox *Sandbox
+	// maximum number of bytes the script may hold at once in strings, environments and functions. Zero means no limit
+	MemoryLimit int64
+}
+
 type Executor struct {
 	statements []ast.Stmt
 	env        *Environment
 	global     *Environment
 	locals     map[ast.Expr]int
+	// --- natives the sandbox did not grant, by name
+	sandbox  Sandbox
+	disabled map[string]Capability
+	// --- active function calls, innermost last
+	frames   []frame
+	maxDepth int
+	// --- execution budget, checked by step and checkContext
+	ctx      context.Context
+	steps    int
+	maxSteps int
+	// --- bytes currently held by the script and the scopes entered, innermost last. See allocate
+	allocated   int64
+	scopes      []*Environment
+	memoryLimit int64
 }
 
-func NewExecutor(stmt []ast.Stmt, env *Environment) *Executor {
-	global := NewEnvironment(env)
-	global.Set("clock", Clock())
+// --- env is used as the global environment, so that state persists across executors (e.g. in the REPL).
+// If it is nil, a fresh global environment is created
+func NewExecutor(stmt []ast.Stmt, env *Environment, opts Options) *Executor {
+	global := env
+	if global == nil {
+		global = NewEnvironment(nil)
+	}
 
-	return &Executor{
-		statements: stmt,
-		env:        global,
-		global:     global,
-		locals:     make(map[ast.Expr]int),
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	maxDepth = min(maxDepth, MaxDepthLimit)
+
+	exec := &Executor{
+		statements:  stmt,
+		env:         global,
+		global:      global,
+		locals:      make(map[ast.Expr]int),
+		maxDepth:    maxDepth,
+		ctx:         context.Background(),
+		maxSteps:    opts.MaxSteps,
+		memoryLimit: opts.MemoryLimit,
 	}
+	exec.installNatives(opts)
+
+	return exec
+}
+
+// replaces the statements run by Execute. Globals, functions and the resolved depths of earlier
+// statements are kept, so that an interactive session can run its input one line at a time
+func (exec *Executor) Load(stmts []ast.Stmt) {
+	exec.statements = stmts
+	exec.env = exec.global
+	exec.steps = 0
 }
 
 func (exec *Executor) Set(key ast.Expr, level int) {
@@ -32,12 +95,16 @@ func (exec *Executor) Set(key ast.Expr, level int) {
 
 func (exec *Executor) setAt(level int, key lexer.Token, value any) (any, error) {
 	var env *Environment = exec.env
-	for count := 0; count < level; level++ {
+	for count := 0; count < level && env != nil; count++ {
 		env = env.enclosing
 	}
+	assert(env != nil, "Expected env to not be nil")
 
+	if err := exec.bind(key, env, key.Literal(), value); err != nil {
+		return nil, err
+	}
 	env.Set(key.Literal(), value)
-	return nil, nil
+	return value, nil
 }
 
 func (exec *Executor) getAt(level int, key lexer.Token) (any, error) {
@@ -49,17 +116,25 @@ func (exec *Executor) getAt(level int, key lexer.Token) (any, error) {
 
 	v, ok := env.store[key.Literal()]
 	if !ok {
-		return nil, NewRuntimeError(key, fmt.Sprintf("undefined variable name '%s'", key.Literal()))
+		return nil, NewNameError(key, fmt.Sprintf("undefined variable name '%s'", key.Literal()))
 	}
 	return v, nil
 }
 
 // main executor function
 func (exec *Executor) Execute() (any, error) {
+	return exec.ExecuteContext(context.Background())
+}
+
+// like Execute, but stops with an InterruptError as soon as ctx is done
+func (exec *Executor) ExecuteContext(ctx context.Context) (any, error) {
+	exec.ctx = ctx
+	defer func() { exec.ctx = context.Background() }()
+
 	for _, s := range exec.statements {
 		_, err := exec.execStatement(s)
 		if err != nil {
-			return nil, err
+			return nil, exec.withTrace(err)
 		}
 	}
 
@@ -70,7 +145,31 @@ func (exec *Executor) reset(env *Environment) {
 	exec.env = env
 }
 
+// accounts for one evaluated statement or expression, failing once the step budget is exhausted
+func (exec *Executor) step() error {
+	exec.steps++
+	if exec.maxSteps > 0 && exec.steps > exec.maxSteps {
+		return NewInterruptError(ErrStepBudgetExceeded)
+	}
+
+	return nil
+}
+
+// fails if the context was cancelled or timed out. Called at loop back-edges and function calls,
+// which is enough to bound how long a script keeps running after cancellation
+func (exec *Executor) checkContext() error {
+	if err := exec.ctx.Err(); err != nil {
+		return NewInterruptError(err)
+	}
+
+	return nil
+}
+
 func (exec *Executor) execStatement(stmt ast.Stmt) (any, error) {
+	if err := exec.step(); err != nil {
+		return nil, err
+	}
+
 	switch s := stmt.(type) {
 	case *ast.FunctionStatement:
 		return exec.execFunctionStatement(s)
@@ -83,42 +182,125 @@ func (exec *Executor) execStatement(stmt ast.Stmt) (any, error) {
 	case *ast.VariableStatement:
 		return exec.execVariableStatement(s)
 	case *ast.BlockStatement:
-		return exec.execBlockStatement(s, NewEnvironment(exec.env))
+		env, err := exec.newEnvironment(s.Brace, exec.env)
+		if err != nil {
+			return nil, err
+		}
+		return exec.execBlockStatement(s, env)
 	case *ast.WhileStatement:
 		return exec.execWhileStatement(s)
 	case *ast.ForStatement:
 		return exec.execForStatement(s)
 	case *ast.ReturnStatement:
 		return exec.execReturnStatement(s)
+	case *ast.ThrowStatement:
+		return exec.execThrowStatement(s)
+	case *ast.TryStatement:
+		return exec.execTryStatement(s)
+	case *ast.MatchStatement:
+		return exec.execMatchStatement(s)
 	}
 
 	return nil, nil
 }
 
 func (exec *Executor) execReturnStatement(s *ast.ReturnStatement) (any, error) {
-	ret, err := exec.execExpr(s.Expression)
+	var ret any = nil
+	if s.Expression != nil {
+		var err error
+		ret, err = exec.execExpr(s.Expression)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	panic(NewReturnValue(ret))
+}
+
+func (exec *Executor) execThrowStatement(s *ast.ThrowStatement) (any, error) {
+	value, err := exec.execExpr(s.Expression)
 	if err != nil {
 		return nil, err
 	}
 
-	panic(NewReturnValue(ret))
+	return nil, NewThrownError(s.Keyword, value)
+}
+
+func (exec *Executor) execTryStatement(s *ast.TryStatement) (r any, e error) {
+	if s.Finally != nil {
+		defer func() {
+			// --- returns unwind as a ReturnValue panic, so the finally block runs on the way out
+			raw := recover()
+
+			env, err := exec.newEnvironment(s.Keyword, exec.env)
+			if err == nil {
+				_, err = exec.execBlock(s.Finally, env)
+			}
+			if err != nil {
+				// --- an error in the finally block replaces whatever was propagating
+				r, e = nil, err
+				return
+			}
+
+			if raw != nil {
+				panic(raw)
+			}
+		}()
+	}
+
+	env, err := exec.newEnvironment(s.Keyword, exec.env)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = exec.execBlock(s.Body, env)
+	if err == nil || s.Catch == nil {
+		return nil, err
+	}
+
+	// --- only runtime errors can be caught: exit() and interruptions keep unwinding
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		return nil, err
+	}
+
+	caught := runtimeErr.caughtValue(err)
+	env, err = exec.newEnvironment(s.Catch.Name, exec.env)
+	if err != nil {
+		return nil, err
+	}
+	if err := exec.bind(s.Catch.Name, env, s.Catch.Name.Literal(), caught); err != nil {
+		return nil, err
+	}
+	env.Set(s.Catch.Name.Literal(), caught)
+
+	return exec.execBlock(s.Catch.Body, env)
 }
 
 func (exec *Executor) execFunctionStatement(s *ast.FunctionStatement) (any, error) {
-	exec.env.Set(s.Name.Literal(), NewGoloxFunction(*s, exec.env))
-	return nil, nil
+	fun := NewGoloxFunction(*s, exec.env)
+	if err := exec.bind(s.Name, exec.env, s.Name.Literal(), fun); err != nil {
+		return nil, err
+	}
+
+	capture(exec.env)
+	return nil, exec.env.Define(s.Name, fun, false)
 }
 
 func (exec *Executor) execForStatement(s *ast.ForStatement) (any, error) {
-	switch init := s.Initializer.(type) {
-	case *ast.VariableStatement:
-		value, err := exec.execExpr(*init.Initializer)
-		if err != nil {
-			return nil, err
-		}
-		exec.env.Set(init.Name.Literal(), value)
-	case *ast.ExpressionStatement:
-		_, err := exec.execExpr(*&init.Expression)
+	// --- the initializer gets its own scope, enclosing every iteration
+	env, err := exec.newEnvironment(s.Keyword, exec.env)
+	if err != nil {
+		return nil, err
+	}
+
+	defer exec.release(env)
+	previous := exec.env
+	defer exec.reset(previous)
+	exec.env = env
+
+	if s.Initializer != nil {
+		_, err := exec.execStatement(s.Initializer)
 		if err != nil {
 			return nil, err
 		}
@@ -135,11 +317,17 @@ func (exec *Executor) execForStatement(s *ast.ForStatement) (any, error) {
 			return nil, err
 		}
 
-		_, err = exec.execExpr(s.Increment)
-		if err != nil {
+		if err := exec.checkContext(); err != nil {
 			return nil, err
 		}
 
+		if s.Increment != nil {
+			_, err = exec.execExpr(s.Increment)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		cond, err = exec.execExpr(s.Condition)
 		if err != nil {
 			return nil, err
@@ -161,6 +349,10 @@ func (exec *Executor) execWhileStatement(s *ast.WhileStatement) (any, error) {
 			return nil, err
 		}
 
+		if err := exec.checkContext(); err != nil {
+			return nil, err
+		}
+
 		cond, err = exec.execExpr(s.Condition)
 		if err != nil {
 			return nil, err
@@ -170,6 +362,52 @@ func (exec *Executor) execWhileStatement(s *ast.WhileStatement) (any, error) {
 	return nil, nil
 }
 
+// runs the body of the first case with a pattern equal to the value, as decided by IsEqual.
+// Patterns are evaluated in order, and only until one matches
+func (exec *Executor) execMatchStatement(s *ast.MatchStatement) (any, error) {
+	value, err := exec.execExpr(s.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, matchCase := range s.Cases {
+		for _, pattern := range matchCase.Patterns {
+			candidate, err := exec.execExpr(pattern)
+			if err != nil {
+				return nil, err
+			}
+
+			if IsEqual(value, candidate) {
+				return exec.execMatchCase(matchCase)
+			}
+		}
+	}
+
+	if s.Default != nil {
+		return exec.execMatchCase(s.Default)
+	}
+	return nil, nil
+}
+
+// --- each case body is a scope of its own
+func (exec *Executor) execMatchCase(matchCase *ast.MatchCase) (any, error) {
+	env, err := exec.newEnvironment(matchCase.Keyword, exec.env)
+	if err != nil {
+		return nil, err
+	}
+
+	return exec.execBlock(matchCase.Body, env)
+}
+
+// evaluates the default value of a parameter in the environment of the call, where the parameters before it are bound
+func (exec *Executor) evalDefault(expr ast.Expr, env *Environment) (any, error) {
+	previous := exec.env
+	defer exec.reset(previous)
+	exec.env = env
+
+	return exec.execExpr(expr)
+}
+
 func (exec *Executor) execConditionalStatement(s *ast.ConditionalStatement) (any, error) {
 	condition, err := exec.execExpr(s.Condition)
 	if err != nil {
@@ -188,6 +426,7 @@ func (exec *Executor) execBlockStatement(s *ast.BlockStatement, env *Environment
 }
 
 func (exec *Executor) execBlock(statements []ast.Stmt, env *Environment) (any, error) {
+	defer exec.release(env)
 	previous := exec.env
 	defer exec.reset(previous)
 
@@ -215,8 +454,11 @@ func (exec *Executor) execVariableStatement(s *ast.VariableStatement) (any, erro
 		}
 	}
 
-	exec.env.Set(s.Name.Literal(), init)
-	return nil, nil
+	if err := exec.bind(s.Name, exec.env, s.Name.Literal(), init); err != nil {
+		return nil, err
+	}
+
+	return nil, exec.env.Define(s.Name, init, s.Constant)
 }
 
 func (exec *Executor) execExpressionStatement(s *ast.ExpressionStatement) (any, error) {
@@ -241,11 +483,17 @@ func (exec *Executor) execPrintStatement(s *ast.PrintStatement) (any, error) {
 }
 
 func (exec *Executor) execExpr(expr ast.Expr) (any, error) {
+	if err := exec.step(); err != nil {
+		return nil, err
+	}
+
 	switch e := expr.(type) {
 	case *ast.Call:
 		return exec.execCall(e)
 	case *ast.Logical:
 		return exec.execLogical(e)
+	case *ast.Conditional:
+		return exec.execConditional(e)
 	case *ast.Binary:
 		return exec.execBinary(e)
 	case *ast.Unary:
@@ -258,6 +506,14 @@ func (exec *Executor) execExpr(expr ast.Expr) (any, error) {
 		return exec.execVariable(e)
 	case *ast.Assignment:
 		return exec.execAssignment(e)
+	case *ast.Get:
+		return exec.execGet(e)
+	case *ast.Index:
+		return exec.execIndex(e)
+	case *ast.Interpolation:
+		return exec.execInterpolation(e)
+	case *ast.Update:
+		return exec.execUpdate(e)
 	}
 
 	return nil, nil
@@ -267,8 +523,10 @@ func Stringify(result any) string {
 	switch t := result.(type) {
 	case *string:
 		return *t
+	case int64:
+		return strconv.FormatInt(t, 10)
 	case float64:
-		return strconv.FormatFloat(t, 'f', -1, 64)
+		return formatFloat(t)
 	case string:
 		return t
 	}
@@ -1,12 +1,20 @@
 package executor
 
 import (
+	"errors"
 	"fmt"
 	"golox/src/ast"
 	"golox/src/lexer"
+	"golox/src/suggest"
+	"math"
+	"strings"
 )
 
 func (exec *Executor) execCall(call *ast.Call) (any, error) {
+	if err := exec.checkContext(); err != nil {
+		return nil, err
+	}
+
 	callee, err := exec.execExpr(call.Callee)
 	if err != nil {
 		return nil, err
@@ -15,12 +23,7 @@ func (exec *Executor) execCall(call *ast.Call) (any, error) {
 	// --- if callee is not callable, runtime error
 	callable, ok := callee.(Callable)
 	if !ok {
-		return nil, NewRuntimeError(call.Paren, "expression is not callable")
-	}
-
-	// --- check arity
-	if callable.arity() != len(call.Args) {
-		return nil, NewRuntimeError(call.Paren, fmt.Sprintf("invalid number of arguments: expected %d but got %d\n", callable.arity(), len(call.Args)))
+		return nil, NewNotCallableError(call.Paren, callee)
 	}
 
 	args := make([]any, 0)
@@ -33,7 +36,23 @@ func (exec *Executor) execCall(call *ast.Call) (any, error) {
 		args = append(args, val)
 	}
 
-	return callable.call(exec, args)
+	named := make([]namedArgument, 0, len(call.Named))
+	for _, arg := range call.Named {
+		val, err := exec.execExpr(arg.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		named = append(named, namedArgument{name: arg.Name, value: val})
+	}
+
+	// --- check arity, and move named arguments into place
+	args, err = bindArguments(callable, call.Paren, args, named)
+	if err != nil {
+		return nil, err
+	}
+
+	return callable.call(exec, call.Paren, args)
 }
 
 func (exec *Executor) execAssignment(expr *ast.Assignment) (any, error) {
@@ -45,9 +64,41 @@ func (exec *Executor) execAssignment(expr *ast.Assignment) (any, error) {
 	level, ok := exec.locals[expr]
 	if ok {
 		return exec.setAt(level, expr.Name, value)
-	} else {
-		return exec.env.Assign(expr.Name, value)
 	}
+
+	if holder := exec.env.holder(expr.Name.Literal()); holder != nil {
+		if err := exec.bind(expr.Name, holder, expr.Name.Literal(), value); err != nil {
+			return nil, err
+		}
+	}
+	value, err = exec.env.Assign(expr.Name, value)
+	if err != nil {
+		return nil, exec.withSuggestions(err)
+	}
+	return value, nil
+}
+
+// increments or decrements a variable holding a number
+func (exec *Executor) execUpdate(expr *ast.Update) (any, error) {
+	old, err := exec.execExpr(expr.Variable)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- checked here, as '+' would otherwise append to strings
+	if !isNumber(old) {
+		return nil, NewTypeError(expr.Operator, fmt.Sprintf("operand of '%s' is not a number", expr.Operator.Type()), "number", old)
+	}
+
+	updated, err := exec.execAssignment(expr.Assignment)
+	if err != nil {
+		return nil, err
+	}
+
+	if expr.Prefix {
+		return updated, nil
+	}
+	return old, nil
 }
 
 func (exec *Executor) execLogical(expr *ast.Logical) (any, error) {
@@ -56,6 +107,14 @@ func (exec *Executor) execLogical(expr *ast.Logical) (any, error) {
 		return nil, left_err
 	}
 
+	// --- '??' only evaluates its right side when the left one is nil
+	if expr.Operator.TokenType() == lexer.QUESTION_QUESTION {
+		if left != nil {
+			return left, nil
+		}
+		return exec.execExpr(expr.Right)
+	}
+
 	// short circuit, if appropriate
 	leftIsTruthy := isTruthy(left)
 	if expr.Operator.TokenType() == lexer.AND && !leftIsTruthy {
@@ -67,6 +126,19 @@ func (exec *Executor) execLogical(expr *ast.Logical) (any, error) {
 	return exec.execExpr(expr.Right)
 }
 
+func (exec *Executor) execConditional(expr *ast.Conditional) (any, error) {
+	condition, err := exec.execExpr(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- only the chosen branch is evaluated
+	if isTruthy(condition) {
+		return exec.execExpr(expr.Then)
+	}
+	return exec.execExpr(expr.Else)
+}
+
 func (exec *Executor) execBinary(expr *ast.Binary) (any, error) {
 	left, left_err := exec.execExpr(expr.Left)
 	if left_err != nil {
@@ -79,9 +151,13 @@ func (exec *Executor) execBinary(expr *ast.Binary) (any, error) {
 
 	switch expr.Operator.TokenType() {
 	// arithmetic operators
-	case lexer.MINUS, lexer.STAR, lexer.SLASH:
+	case lexer.MINUS, lexer.STAR, lexer.SLASH, lexer.PERCENT, lexer.STAR_STAR, lexer.TILDE_SLASH:
 		return handleArithmetic(expr.Operator, left, right)
 
+	// bitwise operators
+	case lexer.AMPERSAND, lexer.PIPE, lexer.CARET, lexer.LESS_LESS, lexer.GREATER_GREATER:
+		return handleBitwise(expr.Operator, left, right)
+
 	// comparison operators
 	case lexer.LESS, lexer.LESS_EQUAL, lexer.GREATER, lexer.GREATER_EQUAL:
 		return handleComparison(expr.Operator, left, right)
@@ -115,12 +191,23 @@ func (exec *Executor) execUnary(expr *ast.Unary) (any, error) {
 
 	switch expr.Operator.TokenType() {
 	case lexer.MINUS:
-		// convert expression to float and invert
-		exprAsFloat, ok := child.(float64)
+		switch n := child.(type) {
+		case int64:
+			if n == math.MinInt64 {
+				return nil, NewOverflowError(expr.Operator, fmt.Sprintf("integer overflow: -(%d) does not fit in 64 bits", n))
+			}
+			return -n, nil
+		case float64:
+			return -n, nil
+		}
+		return nil, NewTypeError(expr.Operator, "unary operator should be a number", "number", child)
+
+	case lexer.TILDE:
+		n, ok := child.(int64)
 		if !ok {
-			return nil, NewRuntimeError(expr.Operator, "unary operator should be a number")
+			return nil, NewTypeError(expr.Operator, "operand of '~' is not an int", "int", child)
 		}
-		return -exprAsFloat, nil
+		return ^n, nil
 
 	case lexer.BANG:
 		return !isTruthy(child), nil
@@ -129,13 +216,83 @@ func (exec *Executor) execUnary(expr *ast.Unary) (any, error) {
 	panic("unreachable")
 }
 
+func (exec *Executor) execGet(expr *ast.Get) (any, error) {
+	object, err := exec.execExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	holder, ok := object.(propertyHolder)
+	if !ok {
+		msg := fmt.Sprintf("can not read property '%s': only errors have properties", expr.Name.Literal())
+		return nil, NewTypeError(expr.Name, msg, "error", object)
+	}
+
+	return holder.get(expr.Name)
+}
+
+func (exec *Executor) execIndex(expr *ast.Index) (any, error) {
+	object, err := exec.execExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := exec.execExpr(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := object.(*List)
+	if !ok {
+		return nil, NewTypeError(expr.Bracket, "only lists can be indexed", "list", object)
+	}
+
+	return list.at(expr.Bracket, index)
+}
+
+// evaluates each part of the string in order and joins their string forms
+func (exec *Executor) execInterpolation(expr *ast.Interpolation) (any, error) {
+	var result strings.Builder
+	for _, part := range expr.Parts {
+		val, err := exec.execExpr(part)
+		if err != nil {
+			return nil, err
+		}
+		result.WriteString(Stringify(val))
+	}
+
+	return result.String(), nil
+}
+
 func (exec *Executor) execVariable(expr *ast.Variable) (any, error) {
 	level, ok := exec.locals[expr]
 	if ok {
 		return exec.getAt(level, expr.Name)
-	} else {
-		return exec.global.Get(expr.Name)
 	}
+
+	val, err := exec.global.Get(expr.Name)
+	if err != nil {
+		if _, disabled := exec.disabled[expr.Name.Literal()]; disabled {
+			return nil, exec.disabledError(expr.Name)
+		}
+		return nil, exec.withSuggestions(err)
+	}
+	return val, nil
+}
+
+// --- adds the names visible from the current environment that are closest to the undefined one to a NameError
+func (exec *Executor) withSuggestions(err error) error {
+	var nameErr *NameError
+	if !errors.As(err, &nameErr) || nameErr.Suggestions != nil {
+		return err
+	}
+
+	nameErr.Suggestions = suggest.Closest(nameErr.Name, exec.env.Names())
+	if hint := suggest.Hint(nameErr.Suggestions); hint != "" {
+		nameErr.Msg = fmt.Sprintf("%s; %s", nameErr.Msg, hint)
+	}
+
+	return err
 }
 
 // consider falsy to be only <nil> or false
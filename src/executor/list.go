@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"golox/src/lexer"
+	"strconv"
+	"strings"
+)
+
+// ordered sequence of values. Lists are created by rest parameters, and read with a subscript
+type List struct {
+	elements []any
+}
+
+func NewList(elements []any) *List {
+	return &List{elements: elements}
+}
+
+// returns the element at index, or nil if index is out of range, like args()
+func (l *List) at(bracket lexer.Token, index any) (any, error) {
+	i, ok := index.(int64)
+	if !ok {
+		return nil, NewTypeError(bracket, "list index must be an int", "int", index)
+	}
+
+	if i < 0 || i >= int64(len(l.elements)) {
+		return nil, nil
+	}
+	return l.elements[i], nil
+}
+
+func (l *List) String() string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, element := range l.elements {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		// --- quoted, so that ["1"] can be told apart from [1]
+		if s, ok := element.(string); ok {
+			sb.WriteString(strconv.Quote(s))
+		} else {
+			sb.WriteString(Stringify(element))
+		}
+	}
+	sb.WriteString("]")
+
+	return sb.String()
+}
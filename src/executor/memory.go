@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"fmt"
+	"golox/src/lexer"
+)
+
+// --- approximate sizes in bytes, used to account for the memory held by scripts
+const (
+	environmentSize = 64
+	bindingSize     = 32
+	functionSize    = 128
+	stringSize      = 16 // header, the contents are accounted for separately
+)
+
+// accounts for size bytes held by env, failing once the memory limit is exceeded. Size is negative
+// when env gives memory back. Held are values about to be stored in env, which must be counted too.
+//
+// Scopes captured by a closure keep their bytes when they are left, as they may still be in use. So
+// before failing, the bytes are counted again from the scopes the script can still reach
+func (exec *Executor) allocate(token lexer.Token, env *Environment, size int, held ...any) error {
+	env.size += int64(size)
+	exec.allocated += int64(size)
+	if size <= 0 || exec.memoryLimit <= 0 || exec.allocated <= exec.memoryLimit {
+		return nil
+	}
+
+	exec.allocated = exec.reachable(env, held)
+	if exec.allocated > exec.memoryLimit {
+		msg := fmt.Sprintf("memory limit exceeded: allocated more than %d bytes", exec.memoryLimit)
+		return NewLimitError(token, msg, "memory", exec.memoryLimit)
+	}
+
+	return nil
+}
+
+// creates a new environment, accounting for it against the memory limit. The scope is entered
+// until it is released
+func (exec *Executor) newEnvironment(token lexer.Token, enclosing *Environment) (*Environment, error) {
+	env := NewEnvironment(enclosing)
+	if err := exec.allocate(token, env, environmentSize); err != nil {
+		return nil, err
+	}
+
+	exec.scopes = append(exec.scopes, env)
+	return env, nil
+}
+
+// accounts for the binding name of env now holding value. Must be called before the binding is
+// updated, so that the bytes of the value it replaces are given back. Constants can not be
+// updated, so there is nothing to account for them
+func (exec *Executor) bind(token lexer.Token, env *Environment, name string, value any) error {
+	if _, constant := env.constants[name]; constant {
+		return nil
+	}
+
+	size := valueSize(value)
+	if old, ok := env.store[name]; ok {
+		size -= valueSize(old)
+	} else {
+		size += bindingSize
+	}
+
+	return exec.allocate(token, env, size, value)
+}
+
+// leaves the scope of env, giving back its bytes unless a closure captured it. Releasing an
+// environment twice is a no-op
+func (exec *Executor) release(env *Environment) {
+	if env.left {
+		return
+	}
+
+	assert(exec.scopes[len(exec.scopes)-1] == env, "scopes must be left in the order they were entered")
+	exec.scopes = exec.scopes[:len(exec.scopes)-1]
+	env.left = true
+	if !env.captured {
+		exec.allocated -= env.size
+	}
+}
+
+// --- marks env and the environments enclosing it as referenced by a closure
+func capture(env *Environment) {
+	for cur := env; cur != nil && !cur.captured; cur = cur.enclosing {
+		cur.captured = true
+	}
+}
+
+// counts the bytes held by the environments reachable from the global one, the scopes entered,
+// env and the values in held
+func (exec *Executor) reachable(env *Environment, held []any) int64 {
+	seen := make(map[*Environment]bool)
+	var size int64
+
+	var markValue func(value any)
+	var markEnv func(env *Environment)
+	markEnv = func(env *Environment) {
+		for ; env != nil && !seen[env]; env = env.enclosing {
+			seen[env] = true
+			size += env.size
+			for _, value := range env.store {
+				markValue(value)
+			}
+		}
+	}
+	markValue = func(value any) {
+		switch v := value.(type) {
+		case *GoloxFunction:
+			markEnv(v.closure)
+		case *List:
+			for _, element := range v.elements {
+				markValue(element)
+			}
+		}
+	}
+
+	markEnv(exec.global)
+	for _, scope := range exec.scopes {
+		markEnv(scope)
+	}
+	markEnv(env)
+	for _, value := range held {
+		// --- only the environments a value refers to are counted here, the value itself is part of env
+		markValue(value)
+	}
+
+	return size
+}
+
+// --- bytes held by a binding to value, besides the binding itself
+func valueSize(value any) int {
+	switch v := value.(type) {
+	case string:
+		return stringSize + len(v)
+	case *GoloxFunction:
+		return functionSize
+	case *List:
+		size := 0
+		for _, element := range v.elements {
+			size += bindingSize + valueSize(element)
+		}
+		return size
+	}
+
+	return 0
+}
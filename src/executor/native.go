@@ -1,17 +1,117 @@
 package executor
 
-import "time"
+import (
+	"fmt"
+	"golox/src/lexer"
+	"math"
+	"os"
+	"time"
+	"unicode/utf8"
+)
 
-type clock struct{}
+// function implemented in Go and exposed to Lox through the global environment
+type native struct {
+	name   string
+	params int
+	// the sandbox must grant this capability for the native to be installed
+	capability Capability
+	fn         func(exec *Executor, paren lexer.Token, args []any) (any, error)
+}
+
+func (n *native) call(executor *Executor, paren lexer.Token, args []any) (any, error) {
+	return n.fn(executor, paren, args)
+}
+
+func (n *native) arity() (int, int) {
+	return n.params, n.params
+}
+
+func (n *native) String() string {
+	return fmt.Sprintf("<native fn %s>", n.name)
+}
+
+// builds the natives NewExecutor installs in the global environment, subject to the sandbox
+func natives(opts Options) []*native {
+	return []*native{
+		{name: "clock", params: 0, capability: CapTime, fn: clock},
+		{name: "argc", params: 0, capability: CapProcess, fn: func(exec *Executor, paren lexer.Token, args []any) (any, error) {
+			return int64(len(opts.Args)), nil
+		}},
+		{name: "args", params: 1, capability: CapProcess, fn: func(exec *Executor, paren lexer.Token, args []any) (any, error) {
+			idx, err := integerArg(paren, "args", args[0])
+			if err != nil {
+				return nil, err
+			}
+
+			// --- out of range indexes evaluate to nil, so scripts can probe for optional arguments
+			if idx < 0 || idx >= len(opts.Args) {
+				return nil, nil
+			}
+			return opts.Args[idx], nil
+		}},
+		{name: "getenv", params: 1, capability: CapEnv, fn: getenv},
+		{name: "exit", params: 1, capability: CapProcess, fn: exit},
+		{name: "len", params: 1, capability: CapCore, fn: length},
+		{name: "int", params: 1, capability: CapCore, fn: func(exec *Executor, paren lexer.Token, args []any) (any, error) {
+			return toInt(paren, args[0])
+		}},
+		{name: "float", params: 1, capability: CapCore, fn: func(exec *Executor, paren lexer.Token, args []any) (any, error) {
+			return toFloatValue(paren, args[0])
+		}},
+	}
+}
+
+// returns the number of elements of a list, or of characters of a string
+func length(exec *Executor, paren lexer.Token, args []any) (any, error) {
+	switch v := args[0].(type) {
+	case *List:
+		return int64(len(v.elements)), nil
+	case string:
+		return int64(utf8.RuneCountInString(v)), nil
+	}
 
-func (c *clock) call(executor *Executor, args []any) any {
-	return time.Now()
+	return nil, NewTypeError(paren, "argument of 'len' must be a list or a string", "list or string", args[0])
 }
 
-func (c *clock) arity() int {
-	return 0
+// returns the number of seconds since the unix epoch
+func clock(exec *Executor, paren lexer.Token, args []any) (any, error) {
+	return float64(time.Now().UnixNano()) / float64(time.Second), nil
+}
+
+// returns the value of the environment variable, or nil if it is not set
+func getenv(exec *Executor, paren lexer.Token, args []any) (any, error) {
+	name, ok := args[0].(string)
+	if !ok {
+		return nil, NewTypeError(paren, "argument of 'getenv' must be a string", "string", args[0])
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, nil
+	}
+	return value, nil
 }
 
-func Clock() *clock {
-	return &clock{}
+// stops execution, requesting the host to exit with the given status code
+func exit(exec *Executor, paren lexer.Token, args []any) (any, error) {
+	code, err := integerArg(paren, "exit", args[0])
+	if err != nil {
+		return nil, err
+	}
+	// --- the OS truncates status codes to a byte, which could turn a failure into a success
+	if code < 0 || code > 255 {
+		return nil, NewTypeError(paren, fmt.Sprintf("exit code must be between 0 and 255, got %d", code), "int between 0 and 255", args[0])
+	}
+
+	return nil, NewExitError(code)
+}
+
+// accepts ints, and floats with an integral value, that fit in 32 bits
+func integerArg(paren lexer.Token, name string, arg any) (int, error) {
+	num, ok := toFloat(arg)
+	if !ok || num != math.Trunc(num) || math.Abs(num) > math.MaxInt32 {
+		return 0, NewTypeError(paren, fmt.Sprintf("argument of '%s' must be an integer", name), "int", arg)
+	}
+
+	return int(num), nil
 }
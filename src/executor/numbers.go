@@ -0,0 +1,235 @@
+package executor
+
+import (
+	"fmt"
+	"golox/src/lexer"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// --- numbers are either exact 64-bit integers (int64) or floats (float64). Integer literals produce
+// ints, and any arithmetic mixing the two promotes the int to a float
+
+// returns the value of a number as a float, and false if val is not a number
+func toFloat(val any) (float64, bool) {
+	switch n := val.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+
+	return 0, false
+}
+
+func isNumber(val any) bool {
+	_, ok := toFloat(val)
+	return ok
+}
+
+// applies an arithmetic operator to two ints, failing if the result does not fit in 64 bits.
+// Division truncates towards zero, and the remainder takes the sign of the dividend
+func intArithmetic(op lexer.Token, l, r int64) (any, error) {
+	var result int64
+	overflow := false
+
+	switch op.TokenType() {
+	case lexer.PLUS:
+		result = l + r
+		overflow = (r > 0 && result < l) || (r < 0 && result > l)
+	case lexer.MINUS:
+		result = l - r
+		overflow = (r < 0 && result < l) || (r > 0 && result > l)
+	case lexer.STAR:
+		result = l * r
+		overflow = l != 0 && (result/l != r || (l == -1 && r == math.MinInt64))
+	case lexer.SLASH, lexer.TILDE_SLASH:
+		if r == 0 {
+			return nil, NewZeroDivisionError(op)
+		}
+		overflow = l == math.MinInt64 && r == -1
+		result = l / r
+	case lexer.PERCENT:
+		if r == 0 {
+			return nil, NewZeroDivisionError(op)
+		}
+		result = l % r
+	case lexer.STAR_STAR:
+		// --- a negative exponent gives a fraction
+		if r < 0 {
+			return math.Pow(float64(l), float64(r)), nil
+		}
+		result, overflow = intPow(l, r)
+	default:
+		panic("unreachable")
+	}
+
+	if overflow {
+		return nil, NewOverflowError(op, fmt.Sprintf("integer overflow: %d %s %d does not fit in 64 bits", l, op.Type(), r))
+	}
+
+	return result, nil
+}
+
+// raises base to a non-negative exponent by squaring, reporting whether the result overflowed
+func intPow(base, exp int64) (int64, bool) {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			next := result * base
+			if base != 0 && (next/base != result || (base == -1 && result == math.MinInt64)) {
+				return 0, true
+			}
+			result = next
+		}
+
+		exp >>= 1
+		if exp > 0 {
+			next := base * base
+			if base != 0 && next/base != base {
+				return 0, true
+			}
+			base = next
+		}
+	}
+
+	return result, false
+}
+
+func floatArithmetic(op lexer.Token, l, r float64) (any, error) {
+	switch op.TokenType() {
+	case lexer.PLUS:
+		return l + r, nil
+	case lexer.MINUS:
+		return l - r, nil
+	case lexer.STAR:
+		return l * r, nil
+	case lexer.SLASH:
+		if r == 0 {
+			return nil, NewZeroDivisionError(op)
+		}
+		return l / r, nil
+	case lexer.TILDE_SLASH:
+		// --- integer division gives an int even for floats
+		if r == 0 {
+			return nil, NewZeroDivisionError(op)
+		}
+		return toInt(op, math.Trunc(l/r))
+	case lexer.PERCENT:
+		if r == 0 {
+			return nil, NewZeroDivisionError(op)
+		}
+		return math.Mod(l, r), nil
+	case lexer.STAR_STAR:
+		return math.Pow(l, r), nil
+	}
+
+	panic("unreachable")
+}
+
+// applies an arithmetic operator to two numbers, which must have been checked with isNumber
+func numberArithmetic(op lexer.Token, left, right any) (any, error) {
+	l, lInt := left.(int64)
+	r, rInt := right.(int64)
+	if lInt && rInt {
+		return intArithmetic(op, l, r)
+	}
+
+	lf, _ := toFloat(left)
+	rf, _ := toFloat(right)
+	return floatArithmetic(op, lf, rf)
+}
+
+// applies a bitwise operator to two ints. Shifting left fails if bits would be lost,
+// shifting right is arithmetic and keeps the sign
+func handleBitwise(op lexer.Token, left, right any) (any, error) {
+	l, ok := left.(int64)
+	if !ok {
+		return nil, NewTypeError(op, fmt.Sprintf("left side of '%s' is not an int", op.Type()), "int", left)
+	}
+
+	r, ok := right.(int64)
+	if !ok {
+		return nil, NewTypeError(op, fmt.Sprintf("right side of '%s' is not an int", op.Type()), "int", right)
+	}
+
+	switch op.TokenType() {
+	case lexer.AMPERSAND:
+		return l & r, nil
+	case lexer.PIPE:
+		return l | r, nil
+	case lexer.CARET:
+		return l ^ r, nil
+	}
+
+	if r < 0 {
+		return nil, NewTypeError(op, fmt.Sprintf("negative shift count %d", r), "non-negative int", right)
+	}
+
+	switch op.TokenType() {
+	case lexer.LESS_LESS:
+		result := l << r
+		if result>>r != l {
+			return nil, NewOverflowError(op, fmt.Sprintf("integer overflow: %d << %d does not fit in 64 bits", l, r))
+		}
+		return result, nil
+	case lexer.GREATER_GREATER:
+		return l >> r, nil
+	}
+
+	panic("unreachable")
+}
+
+// formats floats so that they can't be mistaken for ints: 3.0, 0.5, 6.02e+23
+func formatFloat(f float64) string {
+	abs := math.Abs(f)
+	if abs >= 1e16 || (abs != 0 && abs < 1e-4) {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if math.IsInf(f, 0) || math.IsNaN(f) || strings.Contains(s, ".") {
+		return s
+	}
+	return s + ".0"
+}
+
+// converts a number or a numeric string to an int, truncating floats towards zero
+func toInt(paren lexer.Token, val any) (any, error) {
+	switch n := val.(type) {
+	case int64:
+		return n, nil
+	case float64:
+		// --- float64(math.MaxInt64) rounds up to 2^63, which is already out of range
+		if math.IsNaN(n) || n >= float64(math.MaxInt64) || n < float64(math.MinInt64) {
+			return nil, NewOverflowError(paren, fmt.Sprintf("can not convert %s to int: out of range", formatFloat(n)))
+		}
+		return int64(n), nil
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+		if err != nil {
+			return nil, NewTypeError(paren, fmt.Sprintf("can not convert '%s' to int", n), "number or numeric string", val)
+		}
+		return i, nil
+	}
+
+	return nil, NewTypeError(paren, "argument of 'int' must be a number or a string", "number or string", val)
+}
+
+// converts a number or a numeric string to a float
+func toFloatValue(paren lexer.Token, val any) (any, error) {
+	if f, ok := toFloat(val); ok {
+		return f, nil
+	}
+
+	if s, ok := val.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, NewTypeError(paren, fmt.Sprintf("can not convert '%s' to float", s), "number or numeric string", val)
+		}
+		return f, nil
+	}
+
+	return nil, NewTypeError(paren, "argument of 'float' must be a number or a string", "number or string", val)
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"fmt"
+	"golox/src/lexer"
+	"strings"
+)
+
+// --- group of natives that can be granted to a script
+type Capability int
+
+const (
+	// pure functions, with no access to the host
+	CapCore Capability = 1 << iota
+	// wall clock time
+	CapTime
+	// environment variables
+	CapEnv
+	// process arguments and exit status
+	CapProcess
+)
+
+func (c Capability) String() string {
+	switch c {
+	case CapCore:
+		return "core"
+	case CapTime:
+		return "time"
+	case CapEnv:
+		return "env"
+	case CapProcess:
+		return "process"
+	default:
+		return "unknown"
+	}
+}
+
+// --- decides which natives NewExecutor installs in the global environment
+type Sandbox struct {
+	Name string
+	Caps Capability
+}
+
+var (
+	// every native is available, for trusted scripts
+	SandboxTrusted = Sandbox{Name: "trusted", Caps: CapCore | CapTime | CapEnv | CapProcess}
+	// no access to the environment or the process, for user-authored scripts
+	SandboxRestricted = Sandbox{Name: "restricted", Caps: CapCore | CapTime}
+)
+
+// returns the predefined sandbox with the given name
+func SandboxByName(name string) (Sandbox, bool) {
+	for _, sandbox := range []Sandbox{SandboxTrusted, SandboxRestricted} {
+		if sandbox.Name == name {
+			return sandbox, true
+		}
+	}
+
+	return Sandbox{}, false
+}
+
+// lists the names of the predefined sandboxes, for usage messages
+func SandboxNames() string {
+	return strings.Join([]string{SandboxTrusted.Name, SandboxRestricted.Name}, ", ")
+}
+
+func (s Sandbox) Allows(c Capability) bool {
+	return s.Caps&c == c
+}
+
+// installs the natives allowed by the sandbox, remembering the others to report them if referenced.
+// Names already defined are left alone: the REPL reuses its global environment for every line, where
+// the script may have redefined them
+func (exec *Executor) installNatives(opts Options) {
+	sandbox := SandboxTrusted
+	if opts.Sandbox != nil {
+		sandbox = *opts.Sandbox
+	}
+
+	exec.sandbox = sandbox
+	exec.disabled = make(map[string]Capability)
+	for _, n := range natives(opts) {
+		if sandbox.Allows(n.capability) {
+			if _, defined := exec.global.store[n.name]; !defined {
+				exec.global.Set(n.name, n)
+			}
+		} else {
+			exec.disabled[n.name] = n.capability
+		}
+	}
+}
+
+// explains that a name which failed to resolve refers to a native disabled by the sandbox
+func (exec *Executor) disabledError(name lexer.Token) error {
+	capability := exec.disabled[name.Literal()]
+
+	msg := fmt.Sprintf("'%s' is not available: it requires the '%s' capability, which the '%s' sandbox does not grant",
+		name.Literal(), capability, exec.sandbox.Name)
+	return NewNameError(name, msg)
+}
@@ -1,92 +1,109 @@
 package executor
 
 import (
+	"cmp"
+	"fmt"
 	"golox/src/lexer"
-	"strconv"
 )
 
 func handleArithmetic(op lexer.Token, left, right any) (any, error) {
-	l, l_ok := left.(float64)
-	if !l_ok {
-		return nil, NewRuntimeError(op, "left side of arithmetic operation is not a number")
+	if !isNumber(left) {
+		return nil, NewTypeError(op, "left side of arithmetic operation is not a number", "number", left)
 	}
 
-	r, r_ok := right.(float64)
-	if !r_ok {
-		return nil, NewRuntimeError(op, "right side of arithmetic operation is not a number")
+	if !isNumber(right) {
+		return nil, NewTypeError(op, "right side of arithmetic operation is not a number", "number", right)
 	}
 
-	switch op.TokenType() {
-	case lexer.MINUS:
-		return l - r, nil
-	case lexer.STAR:
-		return l * r, nil
-	case lexer.SLASH:
-		if r == 0 {
-			return nil, NewRuntimeError(op, "right side of division can not be is zero")
-		}
-		return l / r, nil
-	}
-
-	panic("unreachable")
+	return numberArithmetic(op, left, right)
 }
 
+// compares two numbers, or two strings byte-wise (which for UTF-8 is the same as comparing code points)
 func handleComparison(op lexer.Token, left, right any) (any, error) {
-	l, l_ok := left.(float64)
-	if !l_ok {
-		return nil, NewRuntimeError(op, "left side of comparison operation is not a number")
-	}
+	switch l := left.(type) {
+	case int64, float64:
+		if !isNumber(right) {
+			return nil, NewTypeError(op, "right side of comparison operation is not a number", "number", right)
+		}
 
-	r, r_ok := right.(float64)
-	if !r_ok {
-		return nil, NewRuntimeError(op, "right side of comparison operation is not a number")
+		// --- ints are compared exactly, unless one side is a float
+		if l, ok := l.(int64); ok {
+			if r, ok := right.(int64); ok {
+				return compare(op, l, r), nil
+			}
+		}
+		lf, _ := toFloat(left)
+		rf, _ := toFloat(right)
+		return compare(op, lf, rf), nil
+
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, NewTypeError(op, "right side of comparison operation is not a string", "string", right)
+		}
+		return compare(op, l, r), nil
 	}
 
+	return nil, NewTypeError(op, "left side of comparison operation must either be a number or a string", "number or string", left)
+}
+
+func compare[T cmp.Ordered](op lexer.Token, l, r T) bool {
 	switch op.TokenType() {
 	case lexer.LESS:
-		return l < r, nil
+		return l < r
 	case lexer.LESS_EQUAL:
-		return l <= r, nil
+		return l <= r
 	case lexer.GREATER:
-		return l > r, nil
+		return l > r
 	case lexer.GREATER_EQUAL:
-		return l >= r, nil
+		return l >= r
 	}
 
 	panic("unreachable")
 }
 
 func handleEquality(op lexer.Token, left, right any) (any, error) {
+	equal := IsEqual(left, right)
+	if op.TokenType() == lexer.BANG_EQUAL {
+		return !equal, nil
+	}
+
+	return equal, nil
+}
+
+// reports whether two Lox values are equal. Values of different types never are, strings
+// compare by content, and functions and errors by identity
+func IsEqual(left, right any) bool {
 	switch l := left.(type) {
+	case nil:
+		return right == nil
 	case bool:
-		if r, ok := right.(bool); ok {
-			switch op.TokenType() {
-			case lexer.EQUAL_EQUAL:
-				return l == r, nil
-			case lexer.BANG_EQUAL:
-				return l != r, nil
-			}
+		r, ok := right.(bool)
+		return ok && l == r
+	case int64:
+		if r, ok := right.(int64); ok {
+			return l == r
 		}
+		r, ok := right.(float64)
+		return ok && float64(l) == r
 	case float64:
-		if r, ok := right.(float64); ok {
-			switch op.TokenType() {
-			case lexer.EQUAL_EQUAL:
-				return l == r, nil
-			case lexer.BANG_EQUAL:
-				return l != r, nil
-			}
-		}
+		r, ok := toFloat(right)
+		return ok && l == r
+	case string:
+		r, ok := right.(string)
+		return ok && l == r
 	}
 
-	return nil, NewRuntimeError(op, "sides of equality operation need to be both booleans or numbers")
+	// --- every other value is a pointer, so this compares identity
+	return left == right
 }
 
 func handlePlus(op lexer.Token, left, right any) (any, error) {
 	switch l := left.(type) {
-	case float64:
+	case int64, float64:
 		// if right is not a number, error
-		if r, ok := right.(float64); ok {
-			return l + r, nil
+		if isNumber(right) {
+			return numberArithmetic(op, l, right)
 		}
 
 	case string:
@@ -95,19 +112,38 @@ func handlePlus(op lexer.Token, left, right any) (any, error) {
 			return l + r, nil
 		}
 
-		// if right is a number, attempt to convert it to a float
-		switch r := right.(type) {
-		case int:
-			rightAsString := strconv.Itoa(r)
-			return l + rightAsString, nil
-		case float64:
-			rightAsFloat := strconv.FormatFloat(r, 'f', -1, 64)
-			return l + rightAsFloat, nil
+		// if right is a number, convert it to a string
+		if isNumber(right) {
+			return l + Stringify(right), nil
 		}
 
 	default:
-		return nil, NewRuntimeError(op, "left side of addition must either be a number or a string")
+		return nil, NewTypeError(op, "left side of addition must either be a number or a string", "number or string", left)
+	}
+
+	return nil, NewTypeError(op, "right side of addition must either be a number or a string", "number or string", right)
+}
+
+// returns the name of the type of a Lox value, for error messages
+func TypeName(val any) string {
+	switch val.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case *ErrorValue:
+		return "error"
+	case *List:
+		return "list"
+	case Callable:
+		return "function"
 	}
 
-	return nil, NewRuntimeError(op, "right side of addition must either be a number or a string")
+	return fmt.Sprintf("%T", val)
 }
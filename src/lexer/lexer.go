@@ -2,6 +2,8 @@ package lexer
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
 type Lexer struct {
@@ -12,6 +14,15 @@ type Lexer struct {
 	//
 	hasError bool
 	tokens   []Token
+	// --- string interpolations currently open, innermost last
+	interpolations []interpolation
+}
+
+// an expression embedded in a string with '${'. depth counts the braces opened
+// inside the expression, so that the '}' closing it can be told apart
+type interpolation struct {
+	start int
+	depth int
 }
 
 func NewLexer(input string) *Lexer {
@@ -33,14 +44,31 @@ func (lex *Lexer) GetTokens() []Token {
 }
 
 func (lex *Lexer) ScanTokens() {
+	lex.skipShebang()
+
 	for lex.cur < len(lex.input) {
 		lex.start = lex.cur
 		lex.scanToken()
 	}
 
+	if len(lex.interpolations) > 0 {
+		lex.logErrorAt("unterminated string interpolation", lex.interpolations[len(lex.interpolations)-1].start)
+	}
+
 	lex.appendToken(EOF, nil)
 }
 
+// skips a leading '#!' line so scripts can be made executable. The '\n' is left in place to keep line numbers correct
+func (lex *Lexer) skipShebang() {
+	if lex.cur != 0 || lex.peek() != '#' || lex.peekNext() != '!' {
+		return
+	}
+
+	for !lex.isAtEnd() && lex.peek() != '\n' {
+		lex.next()
+	}
+}
+
 // scans token and appends it to lex.tokens
 func (lex *Lexer) scanToken() {
 	c := lex.next()
@@ -55,47 +83,128 @@ func (lex *Lexer) scanToken() {
 	case ')':
 		lex.appendToken(RIGHT_PAREN, nil)
 	case '{':
+		if n := len(lex.interpolations); n > 0 {
+			lex.interpolations[n-1].depth += 1
+		}
 		lex.appendToken(LEFT_BRACE, nil)
 	case '}':
+		// --- a '}' closing an interpolation resumes the string it is embedded in
+		if n := len(lex.interpolations); n > 0 {
+			if lex.interpolations[n-1].depth == 0 {
+				if lex.tokens[len(lex.tokens)-1].tokenType == INTERPOLATION {
+					lex.logErrorAt("expected an expression inside '${}'", lex.interpolations[n-1].start)
+				}
+				lex.interpolations = lex.interpolations[:n-1]
+				lex.buildStringToken()
+				return
+			}
+			lex.interpolations[n-1].depth -= 1
+		}
 		lex.appendToken(RIGHT_BRACE, nil)
 	case ',':
 		lex.appendToken(COMMA, nil)
+	case '[':
+		lex.appendToken(LEFT_BRACKET, nil)
+	case ']':
+		lex.appendToken(RIGHT_BRACKET, nil)
 	case '.':
-		lex.appendToken(DOT, nil)
+		if lex.peek() == '.' && lex.peekNext() == '.' {
+			lex.cur += 2
+			lex.appendToken(ELLIPSIS, nil)
+		} else {
+			lex.appendToken(DOT, nil)
+		}
 	case '+':
-		lex.appendToken(PLUS, nil)
+		if lex.matches('+') {
+			lex.appendToken(PLUS_PLUS, nil)
+		} else if lex.matches('=') {
+			lex.appendToken(PLUS_EQUAL, nil)
+		} else {
+			lex.appendToken(PLUS, nil)
+		}
 	case '-':
-		lex.appendToken(MINUS, nil)
+		if lex.matches('-') {
+			lex.appendToken(MINUS_MINUS, nil)
+		} else if lex.matches('=') {
+			lex.appendToken(MINUS_EQUAL, nil)
+		} else {
+			lex.appendToken(MINUS, nil)
+		}
 	case ';':
 		lex.appendToken(SEMICOLON, nil)
+	case ':':
+		lex.appendToken(COLON, nil)
+	case '?':
+		if lex.matches('?') {
+			lex.appendToken(QUESTION_QUESTION, nil)
+		} else {
+			lex.appendToken(QUESTION, nil)
+		}
 	case '*':
-		lex.appendToken(STAR, nil)
-	case '!', '=', '<', '>':
+		if lex.matches('*') {
+			lex.appendToken(STAR_STAR, nil)
+		} else if lex.matches('=') {
+			lex.appendToken(STAR_EQUAL, nil)
+		} else {
+			lex.appendToken(STAR, nil)
+		}
+	case '%':
+		lex.appendToken(PERCENT, nil)
+	case '^':
+		lex.appendToken(CARET, nil)
+	case '~':
+		if lex.matches('/') {
+			lex.appendToken(TILDE_SLASH, nil)
+		} else {
+			lex.appendToken(TILDE, nil)
+		}
+	case '<':
+		if lex.matches('<') {
+			lex.appendToken(LESS_LESS, nil)
+		} else {
+			lex.appendToken(ToTokenType(c, lex.matches('=')), nil)
+		}
+	case '>':
+		if lex.matches('>') {
+			lex.appendToken(GREATER_GREATER, nil)
+		} else {
+			lex.appendToken(ToTokenType(c, lex.matches('=')), nil)
+		}
+	case '!', '=':
 		lex.appendToken(ToTokenType(c, lex.matches('=')), nil)
 	case '"':
-		lex.buildStringToken()
+		if lex.peek() == '"' && lex.peekNext() == '"' {
+			lex.cur += 2
+			lex.buildRawStringToken(`"""`, true)
+		} else {
+			lex.buildStringToken()
+		}
+	case '`':
+		lex.buildRawStringToken("`", false)
 	case '/':
 		// --- if next character is also '/', ignore everything until end of the line
 		if lex.matches('/') {
 			for !lex.isAtEnd() && lex.peek() != '\n' {
 				lex.next()
 			}
+		} else if lex.matches('=') {
+			lex.appendToken(SLASH_EQUAL, nil)
 		} else {
 			lex.appendToken(SLASH, nil)
 		}
 	case '&':
-		// if next character is not '&', log error
-		if !lex.matches('&') {
-			lex.LogError(fmt.Sprintf("unexpected token '%c'", c))
-		} else {
+		// --- '&&' is the logical and, a single '&' the bitwise one
+		if lex.matches('&') {
 			lex.appendToken(AND, nil)
+		} else {
+			lex.appendToken(AMPERSAND, nil)
 		}
 	case '|':
-		// if next character is not '&', log error
-		if !lex.matches('|') {
-			lex.LogError(fmt.Sprintf("unexpected token '%c'", c))
-		} else {
+		// --- '||' is the logical or, a single '|' the bitwise one
+		if lex.matches('|') {
 			lex.appendToken(OR, nil)
+		} else {
+			lex.appendToken(PIPE, nil)
 		}
 	default:
 		if IsDigit(c) {
@@ -103,7 +212,10 @@ func (lex *Lexer) scanToken() {
 		} else if IsAlphaNumeric(c) {
 			lex.buildIdentifierOrReservedToken()
 		} else {
-			lex.LogError(fmt.Sprintf("unexpected token '%c'", c))
+			// --- report the whole character rather than each byte of a multi-byte one
+			r, size := utf8.DecodeRuneInString(lex.input[lex.start:])
+			lex.cur = lex.start + size
+			lex.LogError(fmt.Sprintf("unexpected token '%c'", r))
 		}
 	}
 }
@@ -114,51 +226,47 @@ func (lex *Lexer) buildIdentifierOrReservedToken() {
 	}
 
 	rawString := lex.input[lex.start:lex.cur]
-	switch rawString {
-	case "if":
-		lex.appendToken(IF, nil)
-	case "else":
-		lex.appendToken(ELSE, nil)
-	case "true":
-		lex.appendToken(TRUE, nil)
-	case "false":
-		lex.appendToken(FALSE, nil)
-	case "nil":
-		lex.appendToken(NIL, nil)
-	case "print":
-		lex.appendToken(PRINT, nil)
-	case "return":
-		lex.appendToken(RETURN, nil)
-	case "super":
-		lex.appendToken(SUPER, nil)
-	case "this":
-		lex.appendToken(THIS, nil)
-	case "var":
-		lex.appendToken(VAR, nil)
-	case "for":
-		lex.appendToken(FOR, nil)
-	case "while":
-		lex.appendToken(WHILE, nil)
-	case "fun":
-		lex.appendToken(FUN, nil)
-	case "class":
-		lex.appendToken(CLASS, nil)
-	default:
-		rawIdentifier := lex.input[lex.start:lex.cur]
-		lex.appendToken(IDENTIFIER, &rawIdentifier)
+	if tokenType, ok := keywords[rawString]; ok {
+		lex.appendToken(tokenType, nil)
+		return
 	}
+
+	lex.appendToken(IDENTIFIER, &rawString)
 }
 
+// scans decimal numbers, optionally with a fraction and an exponent (1, 1.5,
+// 6.02e23), and integers in hex (0xFF), binary (0b1010) and octal (0o17).
+// Digits may be separated by single underscores, which are dropped from the literal
 func (lex *Lexer) buildNumericToken() {
-	// --- first half of the number
-	for !lex.isAtEnd() && IsDigit(lex.peek()) {
+	if base, ok := numericBases[lex.peek()]; ok && lex.input[lex.start] == '0' {
+		// --- advance from the base prefix
 		lex.next()
+
+		count, ok := lex.digits(base.isDigit, false)
+		if !ok {
+			return
+		}
+		if count == 0 {
+			lex.logErrorAt(fmt.Sprintf("expected %s digits after '%s'", base.name, lex.input[lex.start:lex.cur]), lex.start)
+			return
+		}
+		if !lex.endOfNumber(fmt.Sprintf("invalid digit '%%c' in %s literal", base.name)) {
+			return
+		}
+
+		lex.appendNumericToken()
+		return
+	}
+
+	// --- first half of the number, whose first digit has already been consumed
+	if _, ok := lex.digits(IsDigit, true); !ok {
+		return
 	}
 
 	// --- if the current char is '.' but the next is not a digit, error
 	if lex.peek() == '.' {
 		if !IsDigit(lex.peekNext()) {
-			lex.LogError("trailing '.'")
+			lex.logErrorAt("trailing '.'", lex.cur)
 			return
 		}
 
@@ -166,27 +274,116 @@ func (lex *Lexer) buildNumericToken() {
 		lex.next()
 
 		// --- second half of the number, if applicable
-		for !lex.isAtEnd() && IsDigit(lex.peek()) {
+		if _, ok := lex.digits(IsDigit, false); !ok {
+			return
+		}
+	}
+
+	// --- exponent, with an optional sign
+	if lex.peek() == 'e' || lex.peek() == 'E' {
+		exponent := lex.cur
+		lex.next()
+		if lex.peek() == '+' || lex.peek() == '-' {
 			lex.next()
 		}
+
+		count, ok := lex.digits(IsDigit, false)
+		if !ok {
+			return
+		}
+		if count == 0 {
+			lex.logErrorAt("expected digits in exponent", exponent)
+			return
+		}
 	}
 
-	rawNumber := lex.input[lex.start:lex.cur]
+	if !lex.endOfNumber("unexpected character '%c' in number literal") {
+		return
+	}
+
+	lex.appendNumericToken()
+}
+
+type numericBase struct {
+	name    string
+	isDigit func(byte) bool
+}
+
+var numericBases = map[byte]numericBase{
+	'x': {"hex", IsHexDigit},
+	'X': {"hex", IsHexDigit},
+	'b': {"binary", func(c byte) bool { return c == '0' || c == '1' }},
+	'B': {"binary", func(c byte) bool { return c == '0' || c == '1' }},
+	'o': {"octal", func(c byte) bool { return c >= '0' && c <= '7' }},
+	'O': {"octal", func(c byte) bool { return c >= '0' && c <= '7' }},
+}
+
+// consumes a run of digits accepted by isDigit, separated by single underscores.
+// afterDigit tells whether the character before the run is a digit. Returns the
+// number of digits consumed, and false if an error was reported
+func (lex *Lexer) digits(isDigit func(byte) bool, afterDigit bool) (int, bool) {
+	count := 0
+	for !lex.isAtEnd() {
+		c := lex.peek()
+		if c == '_' {
+			if !afterDigit || !isDigit(lex.peekNext()) {
+				lex.logErrorAt("'_' must separate digits", lex.cur)
+				return count, false
+			}
+			lex.next()
+			afterDigit = false
+			continue
+		}
+
+		if !isDigit(c) {
+			break
+		}
+		lex.next()
+		count += 1
+		afterDigit = true
+	}
+
+	return count, true
+}
+
+// reports an error if the number is directly followed by a letter or digit, e.g. '0b12' or '3px',
+// skipping the rest of the word so that it is not reported again as an identifier
+func (lex *Lexer) endOfNumber(format string) bool {
+	if !IsAlphaNumeric(lex.peek()) {
+		return true
+	}
+
+	lex.logErrorAt(fmt.Sprintf(format, lex.peek()), lex.cur)
+	for !lex.isAtEnd() && IsAlphaNumeric(lex.peek()) {
+		lex.next()
+	}
+
+	return false
+}
+
+func (lex *Lexer) appendNumericToken() {
+	rawNumber := strings.ReplaceAll(lex.input[lex.start:lex.cur], "_", "")
 	lex.appendToken(NUMBER, &rawNumber)
 }
 
+// scans a string literal, or the rest of one after an interpolation, from the
+// character following lex.start. A segment ending in '${' is appended as an
+// INTERPOLATION token and the lexer goes back to scanning the embedded expression
 func (lex *Lexer) buildStringToken() {
-	for !lex.isAtEnd() && lex.peek() != '"' {
+	for !lex.isAtEnd() && lex.peek() != '"' && !lex.atInterpolation() {
+		// --- the character following a '\' never ends the string
+		if lex.peek() == '\\' {
+			lex.next()
+			if lex.isAtEnd() {
+				break
+			}
+		}
+
 		// --- increment line
 		if lex.peek() == '\n' {
 			lex.line += 1
 		}
 
-		// --- if the current character is '\' and the next is '"', jump twice ahead
-		if lex.peek() == '\\' && lex.peekNext() == '"' {
-			lex.next()
-		}
-
 		lex.next()
 	}
 
@@ -197,7 +394,19 @@ func (lex *Lexer) buildStringToken() {
 	}
 
 	rawString := lex.input[lex.start+1 : lex.cur]
-	parsedString := ParseRawString(rawString)
+	parsedString, err := ParseRawString(rawString)
+	if err != nil {
+		lex.logErrorAt(err.Msg, lex.start+1+err.Offset)
+	}
+
+	if lex.atInterpolation() {
+		// --- skip '${'
+		lex.next()
+		lex.next()
+		lex.appendToken(INTERPOLATION, &parsedString)
+		lex.interpolations = append(lex.interpolations, interpolation{start: lex.cur - 2})
+		return
+	}
 
 	lex.appendToken(STRING, &parsedString)
 
@@ -205,6 +414,40 @@ func (lex *Lexer) buildStringToken() {
 	lex.next()
 }
 
+// scans a string in which everything up to the closing delimiter is taken
+// verbatim, including backslashes and newlines. Text blocks ("""...""") are
+// additionally stripped of their common indentation, see StripIndent
+func (lex *Lexer) buildRawStringToken(delimiter string, dedent bool) {
+	for !lex.isAtEnd() && !strings.HasPrefix(lex.input[lex.cur:], delimiter) {
+		// --- increment line
+		if lex.peek() == '\n' {
+			lex.line += 1
+		}
+
+		lex.next()
+	}
+
+	// --- if we're at the end of the file, error with unterminated string
+	if lex.isAtEnd() {
+		lex.LogError("unterminated raw string")
+		return
+	}
+
+	rawString := lex.input[lex.start+len(delimiter) : lex.cur]
+	if dedent {
+		rawString = StripIndent(rawString)
+	}
+
+	lex.appendToken(STRING, &rawString)
+
+	// --- skip the closing delimiter
+	lex.cur += len(delimiter)
+}
+
+func (lex *Lexer) atInterpolation() bool {
+	return lex.peek() == '$' && lex.peekNext() == '{'
+}
+
 // returns current byte and advances cur
 func (lex *Lexer) next() byte {
 	lex.cur += 1
@@ -258,7 +501,22 @@ func (lex *Lexer) appendToken(tokenType TokenType, literal *string) {
 	lex.tokens = append(lex.tokens, tok)
 }
 
+// reports an error at the start of the token being scanned
 func (lex *Lexer) LogError(err string) {
+	lex.logErrorAt(err, lex.start)
+}
+
+// reports an error at the byte offset pos of the input, as a line and a column counted in characters
+func (lex *Lexer) logErrorAt(err string, pos int) {
 	lex.hasError = true
-	fmt.Printf("[ERROR]: %s at line %d:%d\n", err, lex.line, lex.cur)
+	line, column := lex.position(pos)
+	fmt.Printf("[ERROR]: %s at line %d:%d\n", err, line, column)
+}
+
+func (lex *Lexer) position(pos int) (int, int) {
+	line := 1 + strings.Count(lex.input[:pos], "\n")
+	lineStart := strings.LastIndexByte(lex.input[:pos], '\n') + 1
+	column := 1 + utf8.RuneCountInString(lex.input[lineStart:pos])
+
+	return line, column
 }
@@ -12,13 +12,32 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
 	DOT
+	ELLIPSIS
 	MINUS
+	MINUS_MINUS
+	MINUS_EQUAL
 	PLUS
+	PLUS_PLUS
+	PLUS_EQUAL
 	SEMICOLON
+	COLON
+	QUESTION
+	QUESTION_QUESTION
 	SLASH
+	SLASH_EQUAL
 	STAR
+	STAR_EQUAL
+	PERCENT
+	STAR_STAR
+	TILDE
+	TILDE_SLASH
+	AMPERSAND
+	PIPE
+	CARET
 	//
 	BANG
 	BANG_EQUAL
@@ -28,6 +47,8 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	LESS_LESS
+	GREATER_GREATER
 	//
 	AND
 	OR
@@ -41,12 +62,21 @@ const (
 	SUPER
 	THIS
 	VAR
+	CONST
 	FOR
 	WHILE
 	FUN
 	CLASS
+	TRY
+	CATCH
+	FINALLY
+	THROW
+	MATCH
+	CASE
+	DEFAULT
 	IDENTIFIER
 	STRING
+	INTERPOLATION // --- a string segment followed by an embedded expression: "a ${
 	NUMBER
 )
 
@@ -64,20 +94,58 @@ func (t TokenType) String() string {
 		return "{"
 	case RIGHT_BRACE:
 		return "}"
+	case LEFT_BRACKET:
+		return "["
+	case RIGHT_BRACKET:
+		return "]"
 	case COMMA:
 		return ","
 	case DOT:
 		return "."
+	case ELLIPSIS:
+		return "..."
 	case MINUS:
 		return "-"
+	case MINUS_MINUS:
+		return "--"
+	case MINUS_EQUAL:
+		return "-="
 	case PLUS:
 		return "+"
+	case PLUS_PLUS:
+		return "++"
+	case PLUS_EQUAL:
+		return "+="
 	case SEMICOLON:
 		return ";"
+	case COLON:
+		return ":"
+	case QUESTION:
+		return "?"
+	case QUESTION_QUESTION:
+		return "??"
 	case SLASH:
 		return "/"
+	case SLASH_EQUAL:
+		return "/="
 	case STAR:
 		return "*"
+	case STAR_EQUAL:
+		return "*="
+	case PERCENT:
+		return "%"
+	case STAR_STAR:
+		return "**"
+	case TILDE:
+		return "~"
+	case TILDE_SLASH:
+		return "~/"
+	case AMPERSAND:
+		return "&"
+	case PIPE:
+		return "|"
+	case CARET:
+		return "^"
 	case BANG:
 		return "!"
 	case BANG_EQUAL:
@@ -94,6 +162,10 @@ func (t TokenType) String() string {
 		return "<"
 	case LESS_EQUAL:
 		return "<="
+	case LESS_LESS:
+		return "<<"
+	case GREATER_GREATER:
+		return ">>"
 	case AND:
 		return "and"
 	case OR:
@@ -118,6 +190,8 @@ func (t TokenType) String() string {
 		return "this"
 	case VAR:
 		return "var"
+	case CONST:
+		return "const"
 	case FOR:
 		return "for"
 	case WHILE:
@@ -126,10 +200,26 @@ func (t TokenType) String() string {
 		return "fun"
 	case CLASS:
 		return "class"
+	case TRY:
+		return "try"
+	case CATCH:
+		return "catch"
+	case FINALLY:
+		return "finally"
+	case THROW:
+		return "throw"
+	case MATCH:
+		return "match"
+	case CASE:
+		return "case"
+	case DEFAULT:
+		return "default"
 	case IDENTIFIER:
 		return "IDENTIFIER"
 	case STRING:
 		return "STRING"
+	case INTERPOLATION:
+		return "INTERPOLATION"
 	case NUMBER:
 		return "NUMBER"
 	default:
@@ -137,6 +227,42 @@ func (t TokenType) String() string {
 	}
 }
 
+// reserved words, mapped to their token type
+var keywords = map[string]TokenType{
+	"if":      IF,
+	"else":    ELSE,
+	"true":    TRUE,
+	"false":   FALSE,
+	"nil":     NIL,
+	"print":   PRINT,
+	"return":  RETURN,
+	"super":   SUPER,
+	"this":    THIS,
+	"var":     VAR,
+	"const":   CONST,
+	"for":     FOR,
+	"while":   WHILE,
+	"fun":     FUN,
+	"class":   CLASS,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"throw":   THROW,
+	"match":   MATCH,
+	"case":    CASE,
+	"default": DEFAULT,
+}
+
+// returns every reserved word
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+
+	return words
+}
+
 type Token struct {
 	// offset from the start of the file
 	start int
@@ -199,6 +325,12 @@ func (t Token) Line() int {
 	return t.line
 }
 
+// returns a copy of the token with a different type, for operators the parser desugars into others, e.g. '+=' into '+'
+func (t Token) WithType(tokenType TokenType) Token {
+	t.tokenType = tokenType
+	return t
+}
+
 func NewToken(tokenType TokenType) Token {
 	return Token{
 		start:     0,
@@ -1,21 +1,152 @@
 package lexer
 
-// strips all '\' from the raw string
-func ParseRawString(raw string) string {
-	output := make([]rune, len(raw))
-	inBackslash := false
-
-	for _, c := range raw {
-		if c == '\\' && !inBackslash {
-			inBackslash = true
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EscapeError reports an invalid escape sequence in a string literal. Offset is
+// the byte offset of the '\' within the raw string
+type EscapeError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *EscapeError) Error() string { return e.Msg }
+
+// replaces the escape sequences in the raw contents of a string literal. Anything
+// that is not part of an escape sequence, including multi-byte UTF-8, is copied
+// through byte for byte. Supported escapes are \n, \t, \r, \\, \", \$, \xHH and
+// \u{H...}. On error, the string decoded so far is returned along with the
+// position of the offending escape
+func ParseRawString(raw string) (string, *EscapeError) {
+	var output strings.Builder
+	output.Grow(len(raw))
+
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			output.WriteByte(raw[i])
+			continue
+		}
+
+		if i+1 >= len(raw) {
+			return output.String(), &EscapeError{Offset: i, Msg: "unterminated escape sequence"}
+		}
+
+		start := i
+		i += 1
+		switch raw[i] {
+		case 'n':
+			output.WriteByte('\n')
+		case 't':
+			output.WriteByte('\t')
+		case 'r':
+			output.WriteByte('\r')
+		case '\\':
+			output.WriteByte('\\')
+		case '"':
+			output.WriteByte('"')
+		case '$':
+			output.WriteByte('$')
+		case 'x':
+			// --- exactly two hex digits, naming a code point between U+0000 and U+00FF
+			if i+3 > len(raw) || !isHexString(raw[i+1:i+3]) {
+				return output.String(), &EscapeError{Offset: start, Msg: "invalid escape sequence: '\\x' must be followed by two hex digits"}
+			}
+			value, _ := strconv.ParseUint(raw[i+1:i+3], 16, 8)
+			output.WriteRune(rune(value))
+			i += 2
+		case 'u':
+			// --- one to six hex digits between braces, naming a valid code point
+			end := strings.IndexByte(raw[i:], '}')
+			if i+1 >= len(raw) || raw[i+1] != '{' || end == -1 {
+				return output.String(), &EscapeError{Offset: start, Msg: "invalid escape sequence: '\\u' must be followed by hex digits between braces, like '\\u{1F600}'"}
+			}
+			digits := raw[i+2 : i+end]
+			if len(digits) == 0 || len(digits) > 6 || !isHexString(digits) {
+				return output.String(), &EscapeError{Offset: start, Msg: "invalid escape sequence: '\\u{...}' must contain between 1 and 6 hex digits"}
+			}
+			value, _ := strconv.ParseUint(digits, 16, 32)
+			if value > unicode.MaxRune || (value >= 0xD800 && value <= 0xDFFF) {
+				return output.String(), &EscapeError{Offset: start, Msg: fmt.Sprintf("invalid escape sequence: U+%X is not a valid code point", value)}
+			}
+			output.WriteRune(rune(value))
+			i += end
+		default:
+			r, _ := utf8.DecodeRuneInString(raw[i:])
+			return output.String(), &EscapeError{Offset: start, Msg: fmt.Sprintf("unknown escape sequence '\\%c'", r)}
+		}
+	}
+
+	return output.String(), nil
+}
+
+// removes the whitespace prefix common to all non-blank lines of a text block.
+// The line holding the opening delimiter and the one holding the closing
+// delimiter are dropped when blank, so that
+//
+//	"""
+//	    SELECT *
+//	      FROM t
+//	    """
+//
+// reads "SELECT *\n  FROM t". Lines that are blank become empty
+func StripIndent(raw string) string {
+	lines := strings.Split(raw, "\n")
+	if len(lines) > 1 && isBlank(lines[0]) {
+		lines = lines[1:]
+	}
+	if len(lines) > 1 && isBlank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := ""
+	found := false
+	for _, line := range lines {
+		if isBlank(line) {
 			continue
 		}
 
-		inBackslash = false
-		output = append(output, c)
+		prefix := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			indent, found = prefix, true
+			continue
+		}
+
+		// --- shorten indent to the prefix it shares with this line
+		i := 0
+		for i < len(indent) && i < len(prefix) && indent[i] == prefix[i] {
+			i++
+		}
+		indent = indent[:i]
 	}
 
-	return string(output)
+	for i, line := range lines {
+		if isBlank(line) {
+			lines[i] = ""
+		} else {
+			lines[i] = line[len(indent):]
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func isBlank(line string) bool {
+	return strings.TrimLeft(line, " \t\r") == ""
+}
+
+func isHexString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !IsHexDigit(s[i]) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func IsDigit(c byte) bool {
@@ -29,3 +160,7 @@ func IsAlpha(c byte) bool {
 func IsAlphaNumeric(c byte) bool {
 	return IsAlpha(c) || IsDigit(c)
 }
+
+func IsHexDigit(c byte) bool {
+	return IsDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
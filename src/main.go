@@ -2,65 +2,147 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"golox/src/executor"
 	"golox/src/lexer"
 	"golox/src/parser"
 	"golox/src/resolver"
+	"io"
 	"os"
 )
 
-func run(input string, env *executor.Environment) (any, error) {
+// sysexits-style exit codes
+const (
+	exitOK       = 0
+	exitUsage    = 64 // the command was used incorrectly
+	exitDataErr  = 65 // the input could not be lexed, parsed or resolved
+	exitNoInput  = 66 // the input file could not be read
+	exitSoftware = 70 // the program failed at run time
+)
+
+// errStatic is returned when the input could not be lexed, parsed or resolved.
+// The details have already been reported by the stage that failed
+var errStatic = errors.New("static error")
+
+// lexes, parses and resolves input, loading it into exec to be run
+func compile(input string, exec *executor.Executor) error {
 	lexer := lexer.NewLexer(input)
 	lexer.ScanTokens()
 	if lexer.HasError() {
-		return nil, nil
+		return errStatic
 	}
 
 	parser := parser.NewParser(lexer.GetTokens())
 	parsed, err := parser.Parse()
 	if err != nil {
 		fmt.Printf("%s", err)
-		return nil, nil
+		return errStatic
+	}
+	if parser.HasError() {
+		return errStatic
 	}
 
-	executor := executor.NewExecutor(parsed, env)
-	resolver := resolver.NewResolver(executor)
+	exec.Load(parsed)
+	resolver := resolver.NewResolver(exec)
 	_, err = resolver.Resolve(parsed)
+	for _, warning := range resolver.Warnings() {
+		fmt.Print(warning)
+	}
 	if err != nil {
 		fmt.Printf("%s", err)
-		return nil, nil
+		return errStatic
+	}
+
+	return nil
+}
+
+func run(input string, exec *executor.Executor) error {
+	err := compile(input, exec)
+	if err != nil {
+		return err
 	}
 
-	_, err = executor.Execute()
+	_, err = exec.Execute()
 	if err != nil {
-		fmt.Println(err.Error())
-		return nil, err
+		// --- exit() is a request from the script, not an error to report
+		if !errors.As(err, new(executor.ExitError)) {
+			fmt.Println(err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// maps the result of run or compile to an exit code
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, errStatic) {
+		return exitDataErr
+	}
+
+	var exit executor.ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
 	}
 
-	return nil, nil
+	return exitSoftware
 }
 
-func HandleFileInput(filePath string) {
+// reads the file at filePath, or stdin if filePath is "-"
+func readInput(filePath string) (string, error) {
+	var content []byte
+	var err error
+	if filePath == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(filePath)
+	}
+
+	return string(content), err
+}
+
+func HandleFileInput(filePath string, opts executor.Options) int {
 	// --- load file into memory
-	file, err := os.ReadFile(filePath)
+	input, err := readInput(filePath)
 	if err != nil {
-		fmt.Println("[ERROR]:", err)
-		return
+		fmt.Fprintln(os.Stderr, "[ERROR]:", err)
+		return exitNoInput
 	}
 
-	// --- execution environment
-	env := executor.NewEnvironment(nil)
-	_, runtime_err := run(string(file), env)
-	if runtime_err != nil {
-		os.Exit(70)
+	return HandleSourceInput(input, opts)
+}
+
+func HandleSourceInput(input string, opts executor.Options) int {
+	return exitCode(run(input, executor.NewExecutor(nil, nil, opts)))
+}
+
+// lexes, parses and resolves each file without executing it
+func HandleCheckInput(filePaths []string, opts executor.Options) int {
+	code := exitOK
+	for _, filePath := range filePaths {
+		input, err := readInput(filePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "[ERROR]:", err)
+			return exitNoInput
+		}
+
+		err = compile(input, executor.NewExecutor(nil, nil, opts))
+		if err != nil {
+			code = exitCode(err)
+		}
 	}
+
+	return code
 }
 
-func HandleReplInput() {
+func HandleReplInput(opts executor.Options) int {
 	scanner := bufio.NewScanner(os.Stdin)
-	// --- execution environment
-	env := executor.NewEnvironment(nil)
+	// --- a single executor for the whole session, keeping globals and resolved functions across lines
+	exec := executor.NewExecutor(nil, nil, opts)
 
 	for {
 		fmt.Print(">> ")
@@ -68,22 +150,19 @@ func HandleReplInput() {
 		// --- handle empty input
 		if !scanner.Scan() {
 			fmt.Println("\nExiting...")
-			return
+			return exitOK
 		}
 
 		input := scanner.Text()
-		run(input, env)
+		err := run(input, exec)
+
+		var exit executor.ExitError
+		if errors.As(err, &exit) {
+			return exit.Code
+		}
 	}
 }
 
 func main() {
-	args := os.Args
-
-	if len(args) > 2 {
-		panic("[ERROR]: Usage: golox [file_path]")
-	} else if len(args) == 2 {
-		HandleFileInput(args[1])
-	} else {
-		HandleReplInput()
-	}
+	os.Exit(runCLI(os.Args[1:]))
 }
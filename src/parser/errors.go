@@ -38,7 +38,7 @@ func (parser *Parser) synchronize() {
 		}
 
 		switch parser.prev().TokenType() {
-		case lexer.CLASS, lexer.FUN, lexer.VAR, lexer.FOR, lexer.IF, lexer.WHILE, lexer.PRINT, lexer.RETURN:
+		case lexer.CLASS, lexer.FUN, lexer.VAR, lexer.CONST, lexer.FOR, lexer.IF, lexer.WHILE, lexer.PRINT, lexer.RETURN, lexer.TRY, lexer.THROW, lexer.MATCH:
 			return
 		}
 
@@ -4,21 +4,28 @@ import (
 	"fmt"
 	"golox/src/ast"
 	"golox/src/lexer"
-	"strconv"
 )
 
 func (parser *Parser) expression() (ast.Expr, error) {
 	return parser.assignment()
 }
 
+// --- binary operator each compound assignment operator is desugared into
+var compoundAssignments = map[lexer.TokenType]lexer.TokenType{
+	lexer.PLUS_EQUAL:  lexer.PLUS,
+	lexer.MINUS_EQUAL: lexer.MINUS,
+	lexer.STAR_EQUAL:  lexer.STAR,
+	lexer.SLASH_EQUAL: lexer.SLASH,
+}
+
 func (parser *Parser) assignment() (ast.Expr, error) {
-	expr, err := parser.or()
+	expr, err := parser.conditional()
 	if err != nil {
 		return nil, err
 	}
 
 	// if the next token is an equal sign, test for assignment
-	if parser.matches(lexer.EQUAL) {
+	if parser.matches(lexer.EQUAL, lexer.PLUS_EQUAL, lexer.MINUS_EQUAL, lexer.STAR_EQUAL, lexer.SLASH_EQUAL) {
 		eq := parser.prev()
 
 		value, err := parser.assignment()
@@ -29,6 +36,11 @@ func (parser *Parser) assignment() (ast.Expr, error) {
 		// if the top level expression is not a variable, this is not a valid assignment
 		switch varName := expr.(type) {
 		case *ast.Variable:
+			// --- 'x += 1' is desugared into 'x = x + 1'
+			if op, ok := compoundAssignments[eq.TokenType()]; ok {
+				value = ast.NewBinary(ast.NewVariable(varName.Name), eq.WithType(op), value)
+			}
+
 			assignment := ast.NewAssignment(varName.Name, value)
 			return assignment, nil
 		default:
@@ -40,6 +52,55 @@ func (parser *Parser) assignment() (ast.Expr, error) {
 	return expr, nil
 }
 
+// the branches of a conditional are full expressions, and the else branch may itself be a conditional,
+// so that 'a ? b : c ? d : e' reads as 'a ? b : (c ? d : e)'
+func (parser *Parser) conditional() (ast.Expr, error) {
+	expr, err := parser.coalesce()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.matches(lexer.QUESTION) {
+		then, err := parser.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		if !parser.matches(lexer.COLON) {
+			return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected ':' in conditional expression but got %s", parser.peek().Type()))
+		}
+
+		otherwise, err := parser.conditional()
+		if err != nil {
+			return nil, err
+		}
+
+		return ast.NewConditional(expr, then, otherwise), nil
+	}
+
+	return expr, nil
+}
+
+func (parser *Parser) coalesce() (ast.Expr, error) {
+	expr, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+
+	// if next token is a ??, build right side of the expression
+	for parser.matches(lexer.QUESTION_QUESTION) {
+		op := parser.prev()
+		right, err := parser.or()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = ast.NewLogical(expr, op, right)
+	}
+
+	return expr, nil
+}
+
 func (parser *Parser) or() (ast.Expr, error) {
 	expr, err := parser.and()
 	if err != nil {
@@ -101,12 +162,89 @@ func (parser *Parser) equality() (ast.Expr, error) {
 }
 
 func (parser *Parser) comparison() (ast.Expr, error) {
-	left, err := parser.term()
+	left, err := parser.bitOr()
 	if err != nil {
 		return nil, err
 	}
 
 	for parser.matches(lexer.LESS, lexer.LESS_EQUAL, lexer.GREATER, lexer.GREATER_EQUAL) {
+		operator := parser.prev()
+		right, err := parser.bitOr()
+		if err != nil {
+			return nil, err
+		}
+
+		left = ast.NewBinary(left, operator, right)
+	}
+
+	return left, nil
+}
+
+// bitwise operators bind tighter than comparisons, so that 'a & mask == 0' reads as '(a & mask) == 0'
+func (parser *Parser) bitOr() (ast.Expr, error) {
+	left, err := parser.bitXor()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.matches(lexer.PIPE) {
+		operator := parser.prev()
+		right, err := parser.bitXor()
+		if err != nil {
+			return nil, err
+		}
+
+		left = ast.NewBinary(left, operator, right)
+	}
+
+	return left, nil
+}
+
+func (parser *Parser) bitXor() (ast.Expr, error) {
+	left, err := parser.bitAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.matches(lexer.CARET) {
+		operator := parser.prev()
+		right, err := parser.bitAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = ast.NewBinary(left, operator, right)
+	}
+
+	return left, nil
+}
+
+func (parser *Parser) bitAnd() (ast.Expr, error) {
+	left, err := parser.shift()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.matches(lexer.AMPERSAND) {
+		operator := parser.prev()
+		right, err := parser.shift()
+		if err != nil {
+			return nil, err
+		}
+
+		left = ast.NewBinary(left, operator, right)
+	}
+
+	return left, nil
+}
+
+func (parser *Parser) shift() (ast.Expr, error) {
+	left, err := parser.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.matches(lexer.LESS_LESS, lexer.GREATER_GREATER) {
 		operator := parser.prev()
 		right, err := parser.term()
 		if err != nil {
@@ -144,7 +282,7 @@ func (parser *Parser) factor() (ast.Expr, error) {
 		return nil, err
 	}
 
-	for parser.matches(lexer.SLASH, lexer.STAR) {
+	for parser.matches(lexer.SLASH, lexer.STAR, lexer.PERCENT, lexer.TILDE_SLASH) {
 		operator := parser.prev()
 		right, err := parser.unary()
 		if err != nil {
@@ -159,7 +297,21 @@ func (parser *Parser) factor() (ast.Expr, error) {
 
 func (parser *Parser) unary() (ast.Expr, error) {
 	// if the next token is a negation operator
-	if parser.matches(lexer.BANG, lexer.MINUS) {
+	if parser.matches(lexer.PLUS_PLUS, lexer.MINUS_MINUS) {
+		operator := parser.prev()
+		expr, err := parser.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		variable, ok := expr.(*ast.Variable)
+		if !ok {
+			return nil, NewParsingError(operator, fmt.Sprintf("operand of '%s' must be a variable", operator.Type()))
+		}
+		return ast.NewUpdate(operator, true, variable), nil
+	}
+
+	if parser.matches(lexer.BANG, lexer.MINUS, lexer.TILDE) {
 		operator := parser.prev()
 		expr, err := parser.unary()
 		if err != nil {
@@ -169,7 +321,28 @@ func (parser *Parser) unary() (ast.Expr, error) {
 		return ast.NewUnary(operator, expr), nil
 	}
 
-	return parser.call()
+	return parser.power()
+}
+
+// '**' is right-associative and binds tighter than a unary operator on its left, so that
+// '2 ** 3 ** 2' reads as '2 ** (3 ** 2)' and '-2 ** 2' as '-(2 ** 2)'
+func (parser *Parser) power() (ast.Expr, error) {
+	left, err := parser.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.matches(lexer.STAR_STAR) {
+		operator := parser.prev()
+		right, err := parser.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return ast.NewBinary(left, operator, right), nil
+	}
+
+	return left, nil
 }
 
 func (parser *Parser) call() (ast.Expr, error) {
@@ -184,29 +357,70 @@ func (parser *Parser) call() (ast.Expr, error) {
 			if err != nil {
 				return nil, err
 			}
+		} else if parser.matches(lexer.LEFT_BRACKET) {
+			bracket := parser.prev()
+			index, err := parser.expression()
+			if err != nil {
+				return nil, err
+			}
+			if !parser.matches(lexer.RIGHT_BRACKET) {
+				return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected ']' but got %s", parser.peek().Type()))
+			}
+			calleeOrPrimary = ast.NewIndex(calleeOrPrimary, bracket, index)
+		} else if parser.matches(lexer.DOT) {
+			if !parser.matches(lexer.IDENTIFIER) {
+				return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected property name after '.' but got %s", parser.peek().Type()))
+			}
+			calleeOrPrimary = ast.NewGet(calleeOrPrimary, parser.prev())
 		} else {
 			break
 		}
 	}
 
+	if parser.matches(lexer.PLUS_PLUS, lexer.MINUS_MINUS) {
+		operator := parser.prev()
+		variable, ok := calleeOrPrimary.(*ast.Variable)
+		if !ok {
+			return nil, NewParsingError(operator, fmt.Sprintf("operand of '%s' must be a variable", operator.Type()))
+		}
+		return ast.NewUpdate(operator, false, variable), nil
+	}
+
 	return calleeOrPrimary, nil
 }
 
 func (parser *Parser) callHelper(callee ast.Expr) (ast.Expr, error) {
 	var args = make([]ast.Expr, 0)
+	var named = make([]ast.NamedArg, 0)
 
 	// --- if the next token is not a ')', parse args
 	if parser.peek().TokenType() != lexer.RIGHT_PAREN {
 		for {
-			if len(args) >= 255 {
+			if len(args)+len(named) >= 255 {
 				return nil, NewParsingError(parser.peek(), "function calls can have at most 255 arguments")
 			}
 
-			arg, err := parser.expression()
-			if err != nil {
-				return nil, err
+			// --- 'name: value' passes an argument by name
+			if parser.check(lexer.IDENTIFIER) && parser.checkNext(lexer.COLON) {
+				name := parser.next()
+				parser.next()
+
+				value, err := parser.expression()
+				if err != nil {
+					return nil, err
+				}
+				named = append(named, ast.NamedArg{Name: name, Value: value})
+			} else {
+				if len(named) > 0 {
+					return nil, NewParsingError(parser.peek(), "positional arguments can not follow named ones")
+				}
+
+				arg, err := parser.expression()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
 			}
-			args = append(args, arg)
 
 			// --- if we are not at a comma, break out of the loop
 			if !parser.matches(lexer.COMMA) {
@@ -219,7 +433,39 @@ func (parser *Parser) callHelper(callee ast.Expr) (ast.Expr, error) {
 		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected ')' but got %s\n", parser.peek().Type()))
 	}
 
-	return ast.NewCall(callee, parser.prev(), args), nil
+	return ast.NewCall(callee, parser.prev(), args, named), nil
+}
+
+// parses the rest of a string with embedded expressions, the first INTERPOLATION token having been consumed
+func (parser *Parser) interpolation() (ast.Expr, error) {
+	start := parser.prev()
+	parts := make([]ast.Expr, 0)
+
+	for {
+		if segment := parser.prev().Literal(); segment != "" {
+			parts = append(parts, ast.NewLiteral(segment))
+		}
+
+		expr, err := parser.expression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, expr)
+
+		// --- another segment followed by an embedded expression
+		if parser.matches(lexer.INTERPOLATION) {
+			continue
+		}
+
+		if !parser.matches(lexer.STRING) {
+			return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '}' to close string interpolation but got %s", parser.peek().Type()))
+		}
+		if segment := parser.prev().Literal(); segment != "" {
+			parts = append(parts, ast.NewLiteral(segment))
+		}
+
+		return ast.NewInterpolation(start, parts), nil
+	}
 }
 
 func (parser *Parser) primary() (ast.Expr, error) {
@@ -231,12 +477,14 @@ func (parser *Parser) primary() (ast.Expr, error) {
 		return ast.NewLiteral(nil), nil
 	} else if parser.matches(lexer.STRING) {
 		return ast.NewLiteral(parser.prev().Literal()), nil
+	} else if parser.matches(lexer.INTERPOLATION) {
+		return parser.interpolation()
 	} else if parser.matches(lexer.IDENTIFIER) {
 		return ast.NewVariable(parser.prev()), nil
 	} else if parser.matches(lexer.NUMBER) {
-		num, err := strconv.ParseFloat(parser.prev().Literal(), 64)
+		num, err := parseNumber(parser.prev().Literal())
 		if err != nil {
-			return nil, NewParsingError(parser.prev(), "invalid float")
+			return nil, NewParsingError(parser.prev(), fmt.Sprintf("number literal '%s' is out of range", parser.prev().Literal()))
 		}
 		return ast.NewLiteral(num), nil
 	}
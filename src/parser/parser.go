@@ -6,8 +6,9 @@ import (
 )
 
 type Parser struct {
-	tokens []lexer.Token
-	cur    int
+	tokens   []lexer.Token
+	cur      int
+	hasError bool
 }
 
 func NewParser(tokens []lexer.Token) Parser {
@@ -31,3 +32,5 @@ func (parser *Parser) Parse() ([]ast.Stmt, error) {
 
 	return stmtList, nil
 }
+
+func (parser *Parser) HasError() bool { return parser.hasError }
@@ -17,6 +17,8 @@ func (parser *Parser) declaration() (ast.Stmt, error) {
 	// --- if next token is var, attempt to parse a variable declaration
 	if parser.matches(lexer.VAR) {
 		stmt, err = parser.variableDeclaration()
+	} else if parser.matches(lexer.CONST) {
+		stmt, err = parser.constDeclaration()
 	} else if parser.matches(lexer.FUN) {
 		stmt, err = parser.function()
 	} else {
@@ -25,6 +27,7 @@ func (parser *Parser) declaration() (ast.Stmt, error) {
 
 	if err != nil {
 		fmt.Printf("%s", err)
+		parser.hasError = true
 		parser.synchronize()
 		return nil, nil
 	}
@@ -42,7 +45,8 @@ func (parser *Parser) function() (ast.Stmt, error) {
 		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '(', got %s\n", parser.peek().TokenType()))
 	}
 
-	params := make([]lexer.Token, 0)
+	params := make([]ast.Parameter, 0)
+	var rest *lexer.Token = nil
 	// --- if the next token is not a ')', parse parameters
 	if !parser.check(lexer.RIGHT_PAREN) {
 		for {
@@ -50,11 +54,37 @@ func (parser *Parser) function() (ast.Stmt, error) {
 				return nil, NewParsingError(parser.peek(), "functions cannot take more than 255 parameters")
 			}
 
+			// --- '...name' collects the remaining arguments, and must come last
+			if parser.matches(lexer.ELLIPSIS) {
+				if !parser.matches(lexer.IDENTIFIER) {
+					return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected IDENTIFIER after '...', got %s\n", parser.peek().TokenType()))
+				}
+				name := parser.prev()
+				rest = &name
+
+				if parser.check(lexer.COMMA) || parser.check(lexer.EQUAL) {
+					return nil, NewParsingError(parser.peek(), fmt.Sprintf("rest parameter '%s' must be the last one and can not have a default value", name.Literal()))
+				}
+				break
+			}
+
 			// --- check that next token is identifier and append it to params
 			if !parser.matches(lexer.IDENTIFIER) {
 				return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected IDENTIFIER, got %s\n", parser.peek().TokenType()))
 			}
-			params = append(params, parser.prev())
+			param := ast.Parameter{Name: parser.prev()}
+
+			if parser.matches(lexer.EQUAL) {
+				def, err := parser.expression()
+				if err != nil {
+					return nil, err
+				}
+				param.Default = def
+			} else if len(params) > 0 && params[len(params)-1].Default != nil {
+				// --- otherwise the parameter could only be passed by name
+				return nil, NewParsingError(param.Name, fmt.Sprintf("parameter '%s' without a default value can not follow one with a default value", param.Name.Literal()))
+			}
+			params = append(params, param)
 
 			if !parser.matches(lexer.COMMA) {
 				break
@@ -74,7 +104,7 @@ func (parser *Parser) function() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	return ast.NewFunctionStatement(name, params, body), nil
+	return ast.NewFunctionStatement(name, params, rest, body), nil
 }
 
 func (parser *Parser) variableDeclaration() (ast.Stmt, error) {
@@ -103,6 +133,21 @@ func (parser *Parser) variableDeclaration() (ast.Stmt, error) {
 	return ast.NewVariableStatement(ident, init), nil
 }
 
+func (parser *Parser) constDeclaration() (ast.Stmt, error) {
+	stmt, err := parser.variableDeclaration()
+	if err != nil {
+		return nil, err
+	}
+
+	decl := stmt.(*ast.VariableStatement)
+	if decl.Initializer == nil {
+		return nil, NewParsingError(decl.Name, fmt.Sprintf("constant '%s' must be initialized", decl.Name.Literal()))
+	}
+	decl.Constant = true
+
+	return decl, nil
+}
+
 func (parser *Parser) statement() (ast.Stmt, error) {
 	// --- print statement
 	if parser.matches(lexer.PRINT) {
@@ -117,6 +162,12 @@ func (parser *Parser) statement() (ast.Stmt, error) {
 		return parser.forStatement()
 	} else if parser.matches(lexer.RETURN) {
 		return parser.returnStatement()
+	} else if parser.matches(lexer.TRY) {
+		return parser.tryStatement()
+	} else if parser.matches(lexer.THROW) {
+		return parser.throwStatement()
+	} else if parser.matches(lexer.MATCH) {
+		return parser.matchStatement()
 	}
 
 	// --- parse regular statement
@@ -124,6 +175,27 @@ func (parser *Parser) statement() (ast.Stmt, error) {
 }
 
 func (parser *Parser) returnStatement() (ast.Stmt, error) {
+	keyword := parser.prev()
+
+	// --- the value is optional
+	var expr ast.Expr = nil
+	if !parser.check(lexer.SEMICOLON) {
+		var err error
+		expr, err = parser.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !parser.matches(lexer.SEMICOLON) {
+		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected ';' but got %s", parser.peek().Type()))
+	}
+
+	return ast.NewReturnStatement(keyword, expr), nil
+}
+
+func (parser *Parser) throwStatement() (ast.Stmt, error) {
+	keyword := parser.prev()
 	expr, err := parser.expression()
 	if err != nil {
 		return nil, err
@@ -133,10 +205,137 @@ func (parser *Parser) returnStatement() (ast.Stmt, error) {
 		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected ';' but got %s", parser.peek().Type()))
 	}
 
-	return ast.NewReturnStatement(expr), nil
+	return ast.NewThrowStatement(keyword, expr), nil
+}
+
+func (parser *Parser) tryStatement() (ast.Stmt, error) {
+	keyword := parser.prev()
+	if !parser.matches(lexer.LEFT_BRACE) {
+		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '{' but got %s", parser.peek().TokenType()))
+	}
+	body, err := parser.block()
+	if err != nil {
+		return nil, err
+	}
+
+	// parse catch clause
+	var catch *ast.CatchClause = nil
+	if parser.matches(lexer.CATCH) {
+		if !parser.matches(lexer.LEFT_PAREN) {
+			return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '(' but got %s", parser.peek().TokenType()))
+		}
+		if !parser.matches(lexer.IDENTIFIER) {
+			return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected IDENTIFIER, got %s", parser.peek().TokenType()))
+		}
+		name := parser.prev()
+		if !parser.matches(lexer.RIGHT_PAREN) {
+			return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected ')' but got %s", parser.peek().TokenType()))
+		}
+
+		if !parser.matches(lexer.LEFT_BRACE) {
+			return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '{' but got %s", parser.peek().TokenType()))
+		}
+		catchBody, err := parser.block()
+		if err != nil {
+			return nil, err
+		}
+
+		catch = &ast.CatchClause{Name: name, Body: catchBody}
+	}
+
+	// parse finally clause
+	var finally []ast.Stmt = nil
+	if parser.matches(lexer.FINALLY) {
+		if !parser.matches(lexer.LEFT_BRACE) {
+			return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '{' but got %s", parser.peek().TokenType()))
+		}
+		finally, err = parser.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if catch == nil && finally == nil {
+		return nil, NewParsingError(parser.peek(), "expected 'catch' or 'finally' after 'try' block")
+	}
+
+	return ast.NewTryStatement(keyword, body, catch, finally), nil
+}
+
+func (parser *Parser) matchStatement() (ast.Stmt, error) {
+	keyword := parser.prev()
+	if !parser.matches(lexer.LEFT_PAREN) {
+		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '(' but got %s", parser.peek().TokenType()))
+	}
+	value, err := parser.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !parser.matches(lexer.RIGHT_PAREN) {
+		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected ')' but got %s", parser.peek().TokenType()))
+	}
+	if !parser.matches(lexer.LEFT_BRACE) {
+		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '{' but got %s", parser.peek().TokenType()))
+	}
+
+	cases := make([]*ast.MatchCase, 0)
+	var def *ast.MatchCase = nil
+	for !parser.isAtEnd() && !parser.check(lexer.RIGHT_BRACE) {
+		if def != nil {
+			return nil, NewParsingError(parser.peek(), "the 'default' case must be the last one in a match statement")
+		}
+
+		if !parser.matches(lexer.CASE, lexer.DEFAULT) {
+			return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected 'case' or 'default' but got %s", parser.peek().TokenType()))
+		}
+		matchCase := &ast.MatchCase{Keyword: parser.prev()}
+
+		// parse comma-separated patterns
+		if matchCase.Keyword.TokenType() == lexer.CASE {
+			matchCase.Patterns = make([]ast.Expr, 0)
+			for {
+				pattern, err := parser.expression()
+				if err != nil {
+					return nil, err
+				}
+				matchCase.Patterns = append(matchCase.Patterns, pattern)
+
+				if !parser.matches(lexer.COMMA) {
+					break
+				}
+			}
+		}
+
+		if !parser.matches(lexer.COLON) {
+			return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected ':' but got %s", parser.peek().TokenType()))
+		}
+
+		// parse body, up to the next case or the end of the statement
+		matchCase.Body = make([]ast.Stmt, 0)
+		for !parser.isAtEnd() && !parser.check(lexer.CASE) && !parser.check(lexer.DEFAULT) && !parser.check(lexer.RIGHT_BRACE) {
+			stmt, err := parser.declaration()
+			if err != nil {
+				return nil, err
+			}
+			matchCase.Body = append(matchCase.Body, stmt)
+		}
+
+		if matchCase.Patterns == nil {
+			def = matchCase
+		} else {
+			cases = append(cases, matchCase)
+		}
+	}
+
+	if !parser.matches(lexer.RIGHT_BRACE) {
+		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '}' but got %s", parser.peek().TokenType()))
+	}
+
+	return ast.NewMatchStatement(keyword, value, cases, def), nil
 }
 
 func (parser *Parser) forStatement() (ast.Stmt, error) {
+	keyword := parser.prev()
 	if !parser.matches(lexer.LEFT_PAREN) {
 		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected '(' but got %s", parser.peek().TokenType()))
 	}
@@ -188,7 +387,7 @@ func (parser *Parser) forStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
-	return ast.NewForStatement(initializer, condition, increment, body), nil
+	return ast.NewForStatement(keyword, initializer, condition, increment, body), nil
 }
 
 func (parser *Parser) whileStatement() (ast.Stmt, error) {
@@ -245,12 +444,13 @@ func (parser *Parser) conditionalStatement() (ast.Stmt, error) {
 }
 
 func (parser *Parser) blockStatement() (ast.Stmt, error) {
+	brace := parser.prev()
 	statements, err := parser.block()
 	if err != nil {
 		return nil, err
 	}
 
-	return ast.NewBlockStatement(statements), nil
+	return ast.NewBlockStatement(brace, statements), nil
 }
 
 func (parser *Parser) block() ([]ast.Stmt, error) {
@@ -295,7 +495,12 @@ func (parser *Parser) expressionStatement() (ast.Stmt, error) {
 
 	// if the next token is not a semicolon, this is an invalid expression
 	if !parser.matches(lexer.SEMICOLON) {
-		return nil, NewParsingError(parser.peek(), fmt.Sprintf("expected ';' but got %s\n", parser.peek().Type()))
+		msg := fmt.Sprintf("expected ';' but got %s", parser.peek().Type())
+		// --- a misspelled keyword parses as an identifier, and the statement breaks right after it
+		if hint := keywordHint(expr); hint != "" {
+			msg = fmt.Sprintf("%s; %s", msg, hint)
+		}
+		return nil, NewParsingError(parser.peek(), msg)
 	}
 
 	return ast.NewExpressionStatement(expr), nil
@@ -1,6 +1,12 @@
 package parser
 
-import "golox/src/lexer"
+import (
+	"golox/src/ast"
+	"golox/src/lexer"
+	"golox/src/suggest"
+	"strconv"
+	"strings"
+)
 
 // returns next token to be parsed, advancing cur
 // if parsing is done, returns the last token (maybe not ideal)
@@ -41,6 +47,15 @@ func (parser *Parser) check(cmp lexer.TokenType) bool {
 	return parser.peek().TokenType() == cmp
 }
 
+// checks if the token after the current position matches cmp without iterating
+func (parser *Parser) checkNext(cmp lexer.TokenType) bool {
+	if parser.isAtEnd() {
+		return false
+	}
+
+	return parser.tokens[parser.cur+1].TokenType() == cmp
+}
+
 // returns ths previous token without mutating cur
 func (parser *Parser) prev() lexer.Token {
 	if parser.cur == 0 {
@@ -49,3 +64,36 @@ func (parser *Parser) prev() lexer.Token {
 
 	return parser.tokens[parser.cur-1]
 }
+
+// suggests the keywords closest to the identifier an expression starts with, e.g. 'retrun' in 'retrun x'
+func keywordHint(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.Call:
+			expr = e.Callee
+			continue
+		case *ast.Variable:
+			return suggest.Hint(suggest.Closest(e.Name.Literal(), lexer.Keywords()))
+		}
+
+		return ""
+	}
+}
+
+// parses a NUMBER literal, which the lexer has already validated. Literals
+// with a fraction or an exponent are floats, every other one is an int
+func parseNumber(literal string) (any, error) {
+	if isPrefixed(literal) {
+		// --- base 0 honors the 0x, 0b and 0o prefixes
+		return strconv.ParseInt(literal, 0, 64)
+	}
+
+	if strings.ContainsAny(literal, ".eE") {
+		return strconv.ParseFloat(literal, 64)
+	}
+	return strconv.ParseInt(literal, 10, 64)
+}
+
+func isPrefixed(literal string) bool {
+	return len(literal) > 1 && literal[0] == '0' && strings.ContainsAny(literal[1:2], "xXbBoO")
+}
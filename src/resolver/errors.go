@@ -0,0 +1,40 @@
+package resolver
+
+import (
+	"fmt"
+	"golox/src/lexer"
+)
+
+// --- error found by the resolver, before the program is executed
+type ResolutionError struct {
+	Token lexer.Token
+	Msg   string
+}
+
+func NewResolutionError(token lexer.Token, msg string) ResolutionError {
+	return ResolutionError{
+		Token: token,
+		Msg:   msg,
+	}
+}
+
+func (err ResolutionError) Error() string {
+	return fmt.Sprintf("[ERROR]: resolution error at line %d: %s\n", err.Token.Line(), err.Msg)
+}
+
+// --- suspicious code found by the resolver, which does not stop the program from running
+type Warning struct {
+	Token lexer.Token
+	Msg   string
+}
+
+func NewWarning(token lexer.Token, msg string) Warning {
+	return Warning{
+		Token: token,
+		Msg:   msg,
+	}
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("[WARNING]: resolution warning at line %d: %s\n", w.Token.Line(), w.Msg)
+}
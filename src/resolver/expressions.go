@@ -1,9 +1,9 @@
 package resolver
 
 import (
+	"fmt"
 	"golox/src/ast"
 	"golox/src/lexer"
-	"golox/src/parser"
 )
 
 func (resolver *Resolver) resolveExpr(expr ast.Expr) (any, error) {
@@ -22,6 +22,16 @@ func (resolver *Resolver) resolveExpr(expr ast.Expr) (any, error) {
 		return resolver.resolveLogicalExpression(s)
 	case *ast.Unary:
 		return resolver.resolveExpr(s.Expression)
+	case *ast.Get:
+		return resolver.resolveExpr(s.Object)
+	case *ast.Index:
+		return resolver.resolveIndexExpression(s)
+	case *ast.Conditional:
+		return resolver.resolveConditionalExpression(s)
+	case *ast.Update:
+		return resolver.resolveUpdateExpression(s)
+	case *ast.Interpolation:
+		return resolver.resolveInterpolationExpression(s)
 	case *ast.Literal:
 		return nil, nil
 	}
@@ -30,25 +40,72 @@ func (resolver *Resolver) resolveExpr(expr ast.Expr) (any, error) {
 }
 
 func (resolver *Resolver) resolveLogicalExpression(s *ast.Logical) (any, error) {
-	resolver.resolveExpr(s.Left)
+	_, err := resolver.resolveExpr(s.Left)
+	if err != nil {
+		return nil, err
+	}
 	return resolver.resolveExpr(s.Right)
 }
 
+func (resolver *Resolver) resolveInterpolationExpression(s *ast.Interpolation) (any, error) {
+	for _, part := range s.Parts {
+		_, err := resolver.resolveExpr(part)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (resolver *Resolver) resolveConditionalExpression(s *ast.Conditional) (any, error) {
+	for _, expr := range []ast.Expr{s.Condition, s.Then, s.Else} {
+		_, err := resolver.resolveExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
 func (resolver *Resolver) resolveGroupingExpression(s *ast.Grouping) (any, error) {
 	return resolver.resolveExpr(s.Expression)
 }
 
 func (resolver *Resolver) resolveCallExpression(s *ast.Call) (any, error) {
-	resolver.resolveExpr(s.Callee)
+	_, err := resolver.resolveExpr(s.Callee)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, arg := range s.Args {
-		resolver.resolveExpr(arg)
+		_, err := resolver.resolveExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, arg := range s.Named {
+		_, err := resolver.resolveExpr(arg.Value)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return nil, nil
 }
 
+func (resolver *Resolver) resolveIndexExpression(s *ast.Index) (any, error) {
+	_, err := resolver.resolveExpr(s.Object)
+	if err != nil {
+		return nil, err
+	}
+	return resolver.resolveExpr(s.Index)
+}
+
 func (resolver *Resolver) resolveBinaryExpression(s *ast.Binary) (any, error) {
-	resolver.resolveExpr(s.Left)
+	_, err := resolver.resolveExpr(s.Left)
+	if err != nil {
+		return nil, err
+	}
 	return resolver.resolveExpr(s.Right)
 }
 
@@ -58,28 +115,40 @@ func (resolver *Resolver) resolveAssignmentExpression(s *ast.Assignment) (any, e
 		return nil, err
 	}
 
-	return resolver.resolveLocal(s.Name, s.Value)
+	// --- constant globals are only known at run time, and are checked by the executor
+	if b, _ := resolver.lookup(s.Name); b != nil && b.constant {
+		msg := fmt.Sprintf("can not assign to constant '%s' declared at line %d", s.Name.Literal(), b.decl.Line())
+		return nil, NewResolutionError(s.Name, msg)
+	}
+
+	return resolver.resolveLocal(s.Name, s)
+}
+
+func (resolver *Resolver) resolveUpdateExpression(s *ast.Update) (any, error) {
+	_, err := resolver.resolveExpr(s.Variable)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolver.resolveExpr(s.Assignment)
 }
 
 func (resolver *Resolver) resolveVariableExpression(s *ast.Variable) (any, error) {
-	curScope, scopeOk := resolver.scopes.peek()
-	val, valExistsInScope := (*curScope)[s.Name.Literal()]
-
-	// --- if the variable in question is not defined in the current scope, error out
-	if scopeOk && valExistsInScope && !val {
-		return nil, parser.NewParsingError(s.Name, "invalid variable expression: variable is not defined in the current scope")
+	// --- a variable declared but not yet defined in the current scope is being read in its own initializer
+	if curScope, ok := resolver.scopes.peek(); ok {
+		if b, exists := (*curScope)[s.Name.Literal()]; exists && !b.defined {
+			return nil, NewResolutionError(s.Name, fmt.Sprintf("can not read local variable '%s' in its own initializer", s.Name.Literal()))
+		}
 	}
 
 	return resolver.resolveLocal(s.Name, s)
 }
 
+// --- records the number of scopes between the innermost declaration of name and the current scope.
+// Names not found in any local scope are left unresolved, and looked up as globals at run time
 func (resolver *Resolver) resolveLocal(name lexer.Token, expr ast.Expr) (any, error) {
-	// --- walk up the scopes until variable is found, and execute
-	for i := len(resolver.scopes.items) - 1; i >= 0; i-- {
-		_, exists := resolver.scopes.items[i][name.Literal()]
-		if exists {
-			resolver.executor.Set(expr, len(resolver.scopes.items)-1-i)
-		}
+	if b, depth := resolver.lookup(name); b != nil {
+		resolver.executor.Set(expr, depth)
 	}
 	return nil, nil
 }
@@ -1,19 +1,34 @@
 package resolver
 
 import (
+	"fmt"
 	"golox/src/ast"
 	"golox/src/executor"
+	"golox/src/lexer"
 )
 
+// --- a name declared in a local scope
+type binding struct {
+	// false while the initializer of the variable is being resolved
+	defined  bool
+	constant bool
+	decl     lexer.Token
+}
+
 type Resolver struct {
 	executor *executor.Executor
-	scopes   Stack[map[string]bool]
+	// --- local scopes only: names that are not found are looked up in the global environment at run time
+	scopes Stack[map[string]*binding]
+	// --- number of function bodies being resolved, to reject top-level returns
+	functionDepth int
+	// --- problems that do not stop the program from running, in the order they were found
+	warnings []Warning
 }
 
 func NewResolver(exec *executor.Executor) Resolver {
 	return Resolver{
 		executor: exec,
-		scopes:   NewStack[map[string]bool](),
+		scopes:   NewStack[map[string]*binding](),
 	}
 }
 
@@ -21,29 +36,65 @@ func (resolver *Resolver) Resolve(stmts []ast.Stmt) (any, error) {
 	return resolver.resolveStatements(stmts)
 }
 
+func (resolver *Resolver) Warnings() []Warning {
+	return resolver.warnings
+}
+
+func (resolver *Resolver) warn(token lexer.Token, msg string) {
+	resolver.warnings = append(resolver.warnings, NewWarning(token, msg))
+}
+
 func (resolver *Resolver) beginScope() {
-	resolver.scopes.push(make(map[string]bool))
+	resolver.scopes.push(make(map[string]*binding))
 }
 
 func (resolver *Resolver) endScope() {
 	resolver.scopes.pop()
 }
 
-// --- declares the variable in the top inner-most scope
-func (resolver *Resolver) declare(name string) {
+// --- declares the variable in the top inner-most scope. Globals are not tracked, so they can be redeclared
+func (resolver *Resolver) declare(name lexer.Token) error {
 	curScope, ok := resolver.scopes.peek()
 	if !ok {
-		return
+		return nil
 	}
-	(*curScope)[name] = false
+
+	if _, exists := (*curScope)[name.Literal()]; exists {
+		return NewResolutionError(name, fmt.Sprintf("variable '%s' is already declared in this scope", name.Literal()))
+	}
+
+	(*curScope)[name.Literal()] = &binding{decl: name}
+	return nil
 }
 
 // --- defines the variable in the top inner-most scope
-func (resolver *Resolver) define(name string) {
+func (resolver *Resolver) define(name lexer.Token) {
 	curScope, ok := resolver.scopes.peek()
 	if !ok {
 		return
 	}
 
-	(*curScope)[name] = true
+	(*curScope)[name.Literal()].defined = true
+}
+
+// --- marks a variable declared in the top inner-most scope as constant
+func (resolver *Resolver) defineConstant(name lexer.Token) {
+	curScope, ok := resolver.scopes.peek()
+	if !ok {
+		return
+	}
+
+	(*curScope)[name.Literal()].constant = true
+}
+
+// --- returns the innermost local binding of name and the number of scopes between it and the current scope,
+// or nil if name is not declared in any local scope
+func (resolver *Resolver) lookup(name lexer.Token) (*binding, int) {
+	for i := len(resolver.scopes.items) - 1; i >= 0; i-- {
+		b, exists := resolver.scopes.items[i][name.Literal()]
+		if exists {
+			return b, len(resolver.scopes.items) - 1 - i
+		}
+	}
+	return nil, 0
 }
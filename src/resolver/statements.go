@@ -1,18 +1,20 @@
 package resolver
 
 import (
+	"fmt"
 	"golox/src/ast"
+	"golox/src/executor"
+	"golox/src/lexer"
+	"math"
 )
 
 func (resolver *Resolver) resolveStatements(stmts []ast.Stmt) (any, error) {
-	resolver.beginScope()
 	for _, stmt := range stmts {
 		_, err := resolver.resolveStmt(stmt)
 		if err != nil {
 			return nil, err
 		}
 	}
-	resolver.endScope()
 	return nil, nil
 }
 
@@ -27,71 +29,248 @@ func (resolver *Resolver) resolveStmt(stmt ast.Stmt) (any, error) {
 	case *ast.ExpressionStatement:
 		return resolver.resolveExpr(s.Expression)
 	case *ast.ConditionalStatement:
-		return resolver.resolveConditionalExpression(s)
+		return resolver.resolveConditionalStatement(s)
 	case *ast.PrintStatement:
-		return resolver.resolvePrintExpression(s)
+		return resolver.resolvePrintStatement(s)
 	case *ast.ReturnStatement:
-		return resolver.resolveReturnExpression(s)
+		return resolver.resolveReturnStatement(s)
 	case *ast.WhileStatement:
 		return resolver.resolveWhileStatement(s)
+	case *ast.ForStatement:
+		return resolver.resolveForStatement(s)
+	case *ast.ThrowStatement:
+		return resolver.resolveExpr(s.Expression)
+	case *ast.TryStatement:
+		return resolver.resolveTryStatement(s)
+	case *ast.MatchStatement:
+		return resolver.resolveMatchStatement(s)
+	}
+
+	return nil, nil
+}
+
+func (resolver *Resolver) resolveMatchStatement(s *ast.MatchStatement) (any, error) {
+	_, err := resolver.resolveExpr(s.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- constant patterns seen so far, to warn about cases that can never match
+	seen := make([]any, 0)
+	for _, matchCase := range s.Cases {
+		for _, pattern := range matchCase.Patterns {
+			_, err := resolver.resolveExpr(pattern)
+			if err != nil {
+				return nil, err
+			}
+
+			value, ok := constantValue(pattern)
+			if !ok {
+				continue
+			}
+			for _, previous := range seen {
+				if executor.IsEqual(previous, value) {
+					msg := fmt.Sprintf("duplicate case %s in match statement will never match", executor.Stringify(value))
+					resolver.warn(matchCase.Keyword, msg)
+					break
+				}
+			}
+			seen = append(seen, value)
+		}
+
+		_, err := resolver.resolveBlock(matchCase.Body)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	if s.Default != nil {
+		return resolver.resolveBlock(s.Default.Body)
+	}
 	return nil, nil
 }
 
+// folds expr into the value it always evaluates to: a literal, possibly grouped or negated
+func constantValue(expr ast.Expr) (any, bool) {
+	switch e := expr.(type) {
+	case *ast.Literal:
+		return e.Value, true
+	case *ast.Grouping:
+		return constantValue(e.Expression)
+	case *ast.Unary:
+		if e.Operator.TokenType() != lexer.MINUS {
+			return nil, false
+		}
+		value, ok := constantValue(e.Expression)
+		if !ok {
+			return nil, false
+		}
+		switch n := value.(type) {
+		case int64:
+			if n != math.MinInt64 {
+				return -n, true
+			}
+		case float64:
+			return -n, true
+		}
+	}
+
+	return nil, false
+}
+
+func (resolver *Resolver) resolveTryStatement(s *ast.TryStatement) (any, error) {
+	_, err := resolver.resolveBlock(s.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Catch != nil {
+		_, err := resolver.resolveCatch(s.Catch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.Finally != nil {
+		return resolver.resolveBlock(s.Finally)
+	}
+	return nil, nil
+}
+
+// --- the caught value is bound in the same scope as the catch body
+func (resolver *Resolver) resolveCatch(c *ast.CatchClause) (any, error) {
+	resolver.beginScope()
+	defer resolver.endScope()
+
+	err := resolver.declare(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	resolver.define(c.Name)
+
+	return resolver.resolveStatements(c.Body)
+}
+
+// --- mirrors the executor: the initializer gets its own scope, enclosing every iteration
+func (resolver *Resolver) resolveForStatement(s *ast.ForStatement) (any, error) {
+	resolver.beginScope()
+	defer resolver.endScope()
+
+	if s.Initializer != nil {
+		_, err := resolver.resolveStmt(s.Initializer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := resolver.resolveExpr(s.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Increment != nil {
+		_, err := resolver.resolveExpr(s.Increment)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resolver.resolveStmt(s.Body)
+}
+
 func (resolver *Resolver) resolveWhileStatement(s *ast.WhileStatement) (any, error) {
-	resolver.resolveExpr(s.Condition)
+	_, err := resolver.resolveExpr(s.Condition)
+	if err != nil {
+		return nil, err
+	}
+
 	return resolver.resolveStmt(s.Body)
 }
 
-func (resolver *Resolver) resolveReturnExpression(s *ast.ReturnStatement) (any, error) {
+func (resolver *Resolver) resolveReturnStatement(s *ast.ReturnStatement) (any, error) {
+	if resolver.functionDepth == 0 {
+		return nil, NewResolutionError(s.Keyword, "can not return from top-level code")
+	}
+
 	if s.Expression != nil {
 		return resolver.resolveExpr(s.Expression)
 	}
 	return nil, nil
 }
 
-func (resolver *Resolver) resolvePrintExpression(s *ast.PrintStatement) (any, error) {
+func (resolver *Resolver) resolvePrintStatement(s *ast.PrintStatement) (any, error) {
 	return resolver.resolveExpr(s.Expression)
 }
 
-func (resolver *Resolver) resolveConditionalExpression(s *ast.ConditionalStatement) (any, error) {
-	resolver.resolveStmt(s.IfBranch)
-	resolver.resolveExpr(s.Condition)
+func (resolver *Resolver) resolveConditionalStatement(s *ast.ConditionalStatement) (any, error) {
+	_, err := resolver.resolveExpr(s.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = resolver.resolveStmt(s.IfBranch)
+	if err != nil {
+		return nil, err
+	}
+
 	if s.ElseBranch != nil {
-		resolver.resolveStmt(s.ElseBranch)
+		return resolver.resolveStmt(s.ElseBranch)
 	}
 	return nil, nil
 }
 
 func (resolver *Resolver) resolveFunctionStatement(s *ast.FunctionStatement) (any, error) {
-	resolver.declare(s.Name.Literal())
-	resolver.define(s.Name.Literal())
+	// --- defined before the body is resolved, so the function can refer to itself recursively
+	err := resolver.declare(s.Name)
+	if err != nil {
+		return nil, err
+	}
+	resolver.define(s.Name)
 
 	return resolver.resolveFunction(s)
 }
 
 func (resolver *Resolver) resolveFunction(s *ast.FunctionStatement) (any, error) {
 	resolver.beginScope()
+	resolver.functionDepth++
+	defer func() {
+		resolver.functionDepth--
+		resolver.endScope()
+	}()
+
+	// --- defaults are evaluated at call time, and can refer to the parameters before them
+	for _, param := range s.Parameters {
+		if param.Default != nil {
+			_, err := resolver.resolveExpr(param.Default)
+			if err != nil {
+				return nil, err
+			}
+		}
 
-	for _, tok := range s.Parameters {
-		resolver.declare(tok.Literal())
-		resolver.define(tok.Literal())
+		err := resolver.declare(param.Name)
+		if err != nil {
+			return nil, err
+		}
+		resolver.define(param.Name)
 	}
 
-	for _, stmt := range s.Body {
-		_, err := resolver.resolveStmt(stmt)
+	if s.Rest != nil {
+		err := resolver.declare(*s.Rest)
 		if err != nil {
 			return nil, err
 		}
+		resolver.define(*s.Rest)
 	}
 
-	resolver.endScope()
-	return nil, nil
+	return resolver.resolveStatements(s.Body)
 }
 
 func (resolver *Resolver) resolveVariableStatement(s *ast.VariableStatement) (any, error) {
-	resolver.declare(s.Name.Literal())
+	err := resolver.declare(s.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	// --- if there is an initializer, resolve it
 	if s.Initializer != nil {
 		_, err := resolver.resolveExpr(*s.Initializer)
@@ -99,18 +278,20 @@ func (resolver *Resolver) resolveVariableStatement(s *ast.VariableStatement) (an
 			return nil, err
 		}
 	}
-	resolver.define(s.Name.Literal())
+	resolver.define(s.Name)
+	if s.Constant {
+		resolver.defineConstant(s.Name)
+	}
 	return nil, nil
 }
 
 func (resolver *Resolver) resolveBlockStatement(s *ast.BlockStatement) (any, error) {
+	return resolver.resolveBlock(s.Statements)
+}
+
+func (resolver *Resolver) resolveBlock(stmts []ast.Stmt) (any, error) {
 	resolver.beginScope()
-	for _, stmt := range s.Statements {
-		_, err := resolver.resolveStmt(stmt)
-		if err != nil {
-			return nil, err
-		}
-	}
-	resolver.endScope()
-	return nil, nil
+	defer resolver.endScope()
+
+	return resolver.resolveStatements(stmts)
 }
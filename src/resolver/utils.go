@@ -6,7 +6,7 @@ type Stack[T any] struct {
 
 func NewStack[T any]() Stack[T] {
 	return Stack[T]{
-		items: make([]T, 128),
+		items: make([]T, 0),
 	}
 }
 
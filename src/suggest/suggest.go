@@ -0,0 +1,94 @@
+package suggest
+
+import "sort"
+
+// maximum number of suggestions returned by Closest
+const maxSuggestions = 3
+
+// returns the candidates closest to name by edit distance, best first. Only candidates close
+// enough to plausibly be a typo are returned, so the result is often empty
+func Closest(name string, candidates []string) []string {
+	type match struct {
+		candidate string
+		distance  int
+	}
+
+	// --- allow roughly one edit for every three characters
+	threshold := max(1, (len(name)+1)/3)
+
+	seen := make(map[string]bool)
+	matches := make([]match, 0)
+	for _, candidate := range candidates {
+		if candidate == name || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		if d := Distance(name, candidate); d <= threshold {
+			matches = append(matches, match{candidate: candidate, distance: d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].candidate < matches[j].candidate
+	})
+
+	result := make([]string, 0, maxSuggestions)
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		result = append(result, matches[i].candidate)
+	}
+	return result
+}
+
+// returns the edit distance between a and b, counting insertions, deletions, substitutions
+// and transpositions of adjacent characters as one edit each
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// --- d[i][j] is the distance between the first i runes of a and the first j runes of b
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// formats suggestions as a hint to append to an error message, e.g. "did you mean 'a' or 'b'?"
+func Hint(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	hint := "did you mean "
+	for i, s := range suggestions {
+		if i > 0 && i == len(suggestions)-1 {
+			hint += " or "
+		} else if i > 0 {
+			hint += ", "
+		}
+		hint += "'" + s + "'"
+	}
+	return hint + "?"
+}